@@ -0,0 +1,90 @@
+package httpx
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Limiter is token bucket bandwidth limiter which can be shared between multiple bodies
+// so they don't exceed the bytes per second cap together.
+type Limiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewLimiter returns limiter allowing bytesPerSec bytes per second, zero or negative
+// value doesn't limit the bandwidth.
+func NewLimiter(bytesPerSec int64) *Limiter {
+	l := &Limiter{}
+	l.SetLimit(bytesPerSec)
+	return l
+}
+
+// SetLimit changes the bytes per second cap, it's safe to call while limiter is in use.
+func (l *Limiter) SetLimit(bytesPerSec int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rate = float64(bytesPerSec)
+	// burst of 100ms keeps the transfer smooth
+	l.burst = max(l.rate/10, 1)
+	l.tokens = min(l.tokens, l.burst)
+	l.last = time.Now()
+}
+
+// WaitN accounts n transferred bytes and blocks till they fit within the limit or
+// context is done. Waiters are served in order they called WaitN.
+func (l *Limiter) WaitN(ctx context.Context, n int) error {
+	if l == nil || n <= 0 {
+		return nil
+	}
+	l.mu.Lock()
+	if l.rate <= 0 {
+		l.mu.Unlock()
+		return nil
+	}
+	now := time.Now()
+	l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+	// tokens can go negative, the debt is paid by waiting
+	l.tokens -= float64(n)
+	var wait time.Duration
+	if l.tokens < 0 {
+		wait = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	l.mu.Unlock()
+
+	if wait == 0 {
+		return nil
+	}
+	t := time.NewTimer(wait)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// limited reports whether limiter caps the bandwidth
+func (l *Limiter) limited() bool {
+	if l == nil {
+		return false
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.rate > 0
+}
+
+// SetBandwidth caps the bytes per second of request and response bodies shared by all the
+// requests of client, zero is unlimited. Per request caps can be set with [HTTPOptions.Upload]
+// and [HTTPOptions.Download].
+func (c *Client) SetBandwidth(upload, download int64) *Client {
+	c.uploadLimiter = NewLimiter(upload)
+	c.downloadLimiter = NewLimiter(download)
+	return c
+}
@@ -0,0 +1,159 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"io"
+	"iter"
+	"net/http"
+	"sync"
+	"time"
+
+	"collections/workerpool"
+)
+
+const defaultBatchConcurrency = 10
+
+// ErrBatchAborted is the error of requests which were cancelled or never sent because
+// other request of fail fast batch failed.
+var ErrBatchAborted = errors.New("batch aborted after failed request")
+
+// errBatchStopped cancels the remaining requests when consumer stops the stream
+var errBatchStopped = errors.New("batch stopped")
+
+// BatchRequest is single request of batch.
+type BatchRequest struct {
+	Method  string
+	URL     string
+	Body    io.Reader
+	Options *HTTPOptions
+}
+
+// BatchResult is the result of single request, Index is its position in the batch.
+// Caller must close the response body.
+type BatchResult struct {
+	Index    int
+	Response *http.Response
+	Err      error
+	Duration time.Duration
+}
+
+// BatchOptions configures the batch execution.
+type BatchOptions struct {
+	// Concurrency is maximum requests in flight, default is 10
+	Concurrency int
+	// FailFast cancels the in flight requests and skips the rest once a request fails,
+	// they are reported with [ErrBatchAborted].
+	FailFast bool
+	// Failed decides whether request failed for FailFast, default is non nil error
+	Failed func(*http.Response, error) bool
+}
+
+// Batch executes requests concurrently on worker pool and returns results in input order.
+// Requests which were not sent because context is done are reported with its cause.
+func (c *Client) Batch(ctx context.Context, reqs []BatchRequest, opts BatchOptions) []BatchResult {
+	out := make([]BatchResult, len(reqs))
+	for r := range c.BatchStream(ctx, reqs, opts) {
+		out[r.Index] = r
+	}
+	return out
+}
+
+// BatchStream executes requests concurrently on worker pool and yields results as they
+// complete. Breaking the loop cancels the remaining requests and closes bodies of
+// responses which were not yielded.
+func (c *Client) BatchStream(ctx context.Context, reqs []BatchRequest, opts BatchOptions) iter.Seq[BatchResult] {
+	return func(yield func(BatchResult) bool) {
+		concurrency := opts.Concurrency
+		if concurrency <= 0 {
+			concurrency = defaultBatchConcurrency
+		}
+		failed := opts.Failed
+		if failed == nil {
+			failed = func(_ *http.Response, err error) bool { return err != nil }
+		}
+
+		b := &batch{c: c, ctx: ctx, inflight: map[int]context.CancelCauseFunc{}}
+		wp := workerpool.New[BatchResult](min(concurrency, max(len(reqs), 1)))
+		go wp.Run()
+		go func() {
+			defer wp.Close()
+			for i, r := range reqs {
+				wp.Submit(func() (BatchResult, error) {
+					res := b.exec(i, r)
+					// abort before worker takes next request
+					if opts.FailFast && failed(res.Response, res.Err) {
+						b.abort(ErrBatchAborted)
+					}
+					return res, nil
+				})
+			}
+		}()
+
+		stopped := false
+		for r := range wp.ResCh {
+			res := r.Res
+			if !stopped && !yield(res) {
+				stopped = true
+				b.abort(errBatchStopped)
+				continue
+			}
+			if stopped && res.Response != nil {
+				res.Response.Body.Close()
+			}
+		}
+	}
+}
+
+// batch tracks the in flight requests so they can be cancelled
+type batch struct {
+	c        *Client
+	ctx      context.Context
+	mu       sync.Mutex
+	inflight map[int]context.CancelCauseFunc
+	aborted  error
+}
+
+func (b *batch) abort(cause error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.aborted != nil {
+		return
+	}
+	b.aborted = cause
+	for _, cancel := range b.inflight {
+		cancel(cause)
+	}
+}
+
+func (b *batch) exec(i int, r BatchRequest) BatchResult {
+	b.mu.Lock()
+	if b.aborted != nil {
+		b.mu.Unlock()
+		return BatchResult{Index: i, Err: b.aborted}
+	}
+	if b.ctx.Err() != nil {
+		b.mu.Unlock()
+		return BatchResult{Index: i, Err: context.Cause(b.ctx)}
+	}
+	ctx, cancel := context.WithCancelCause(b.ctx)
+	b.inflight[i] = cancel
+	b.mu.Unlock()
+
+	start := time.Now()
+	res, err := b.c.Exec(ctx, r.Method, r.URL, r.Body, r.Options)
+	b.mu.Lock()
+	delete(b.inflight, i)
+	b.mu.Unlock()
+
+	if err != nil {
+		if ctx.Err() != nil {
+			err = context.Cause(ctx)
+		}
+		cancel(nil)
+		return BatchResult{Index: i, Err: err, Duration: time.Since(start)}
+	}
+	// context must outlive the batch so body can still be read, it's released with the body
+	res.Body = &doneBody{ReadCloser: res.Body, done: func() { cancel(nil) }}
+	return BatchResult{Index: i, Response: res, Duration: time.Since(start)}
+}
@@ -0,0 +1,66 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBatch(t *testing.T) {
+	var inflight, peak atomic.Int32
+	errFail := errors.New("fail")
+	c := New(false).SetTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		n := inflight.Add(1)
+		defer inflight.Add(-1)
+		for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+		}
+		switch req.URL.Path {
+		case "/fail":
+			return nil, errFail
+		case "/slow":
+			<-req.Context().Done()
+			return nil, req.Context().Err()
+		}
+		time.Sleep(5 * time.Millisecond)
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(req.URL.Path))}, nil
+	}))
+
+	var reqs []BatchRequest
+	for i := range 8 {
+		reqs = append(reqs, BatchRequest{Method: http.MethodGet, URL: fmt.Sprintf("http://example.com/%d", i)})
+	}
+	results := c.Batch(context.Background(), reqs, BatchOptions{Concurrency: 3})
+	for i, r := range results {
+		if r.Err != nil || r.Index != i {
+			t.Fatalf("unexpected result %d %+v", i, r)
+		}
+		if body, _ := String(r.Response, nil); body != fmt.Sprintf("/%d", i) {
+			t.Errorf("result %d out of order got %s", i, body)
+		}
+	}
+	if peak.Load() > 3 {
+		t.Errorf("concurrency exceeded got %d", peak.Load())
+	}
+
+	reqs = []BatchRequest{
+		{Method: http.MethodGet, URL: "http://example.com/slow"},
+		{Method: http.MethodGet, URL: "http://example.com/fail"},
+		{Method: http.MethodGet, URL: "http://example.com/slow"},
+		{Method: http.MethodGet, URL: "http://example.com/skipped"},
+	}
+	results = c.Batch(context.Background(), reqs, BatchOptions{Concurrency: 3, FailFast: true})
+	if !errors.Is(results[1].Err, errFail) {
+		t.Errorf("wanted failure of request 1 got %v", results[1].Err)
+	}
+	for _, i := range []int{0, 2, 3} {
+		if !errors.Is(results[i].Err, ErrBatchAborted) {
+			t.Errorf("wanted request %d aborted got %v", i, results[i].Err)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package httpx
+
+import (
+	"io"
+	"net/http"
+
+	"collections/httpx/internal/bodylimit"
+)
+
+// maxDrainSize is the most bytes drained from unread body so connection can be reused,
+// larger bodies are closed and the connection is dropped.
+const maxDrainSize = 64 * 1024
+
+// ErrBodyTooLarge is matched by [BodyTooLargeError] with [errors.Is].
+var ErrBodyTooLarge = bodylimit.ErrTooLarge
+
+// BodyTooLargeError is returned by reads of body which exceeded the limit.
+type BodyTooLargeError = bodylimit.Error
+
+// SetMaxBodySize limits size of every response body returned by Exec including responses of
+// every attempt seen by retry hook, reading past limit fails with [BodyTooLargeError].
+// Zero or negative size is unlimited. Body decompressed by transport is limited by its
+// decompressed size.
+func (c *Client) SetMaxBodySize(n int64) *Client {
+	c.maxBodySize = n
+	return c
+}
+
+// MaxBodySize overrides the limit of response body set with [Client.SetMaxBodySize],
+// negative size disables the client limit for this request.
+func (ho *HTTPOptions) MaxBodySize(n int64) *HTTPOptions {
+	ho.maxBodySize = n
+	return ho
+}
+
+// LimitBody returns body which fails with [BodyTooLargeError] once more than limit bytes are
+// read from rc, declared size of body is reported with the error.
+func LimitBody(rc io.ReadCloser, limit, contentLength int64) io.ReadCloser {
+	return bodylimit.Reader(rc, limit, contentLength)
+}
+
+// bodyLimit returns the response body limit of request or client
+func (c *Client) bodyLimit(ho *HTTPOptions) int64 {
+	if ho.maxBodySize != 0 {
+		return ho.maxBodySize
+	}
+	return c.maxBodySize
+}
+
+// limitBody applies the limit to response body, zero or negative limit is unlimited
+func limitBody(res *http.Response, limit int64) {
+	if res == nil || res.Body == nil || limit <= 0 {
+		return
+	}
+	res.Body = LimitBody(res.Body, limit, res.ContentLength)
+}
+
+// Bytes reads the whole response body and closes it, error of request is returned as is
+// so it can wrap the call e.g. Bytes(c.Get(ctx, uri, nil)). Body is drained when read fails
+// so connection can be reused.
+func Bytes(res *http.Response, err error) ([]byte, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(res)
+	return io.ReadAll(res.Body)
+}
+
+// String is [Bytes] returning string.
+func String(res *http.Response, err error) (string, error) {
+	b, err := Bytes(res, err)
+	return string(b), err
+}
+
+// closeBody drains limited amount of unread body and closes it
+func closeBody(res *http.Response) {
+	_, _ = io.CopyN(io.Discard, res.Body, maxDrainSize)
+	res.Body.Close()
+}
@@ -0,0 +1,86 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestMaxBodySize(t *testing.T) {
+	body := strings.Repeat("a", 100)
+	contentLength := int64(-1)
+	c := New(false).SetMaxBodySize(64).SetTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode:    http.StatusOK,
+			ContentLength: contentLength,
+			Body:          io.NopCloser(strings.NewReader(body)),
+		}, nil
+	}))
+
+	b, err := Bytes(c.Get(context.Background(), "http://example.com", nil))
+	var tooLarge BodyTooLargeError
+	if !errors.Is(err, ErrBodyTooLarge) || !errors.As(err, &tooLarge) || tooLarge.Limit != 64 {
+		t.Fatalf("wanted BodyTooLargeError got %v", err)
+	}
+	if len(b) != 64 {
+		t.Errorf("wanted 64 bytes read before failing got %d", len(b))
+	}
+
+	s, err := String(c.Get(context.Background(), "http://example.com", NewHTTPOptions().MaxBodySize(100)))
+	if err != nil || s != body {
+		t.Errorf("wanted body within request limit got %d bytes, %v", len(s), err)
+	}
+
+	contentLength = int64(len(body))
+	b, err = Bytes(c.Get(context.Background(), "http://example.com", nil))
+	if !errors.Is(err, ErrBodyTooLarge) || len(b) != 0 {
+		t.Errorf("wanted declared content length to fail before reading got %d bytes, %v", len(b), err)
+	}
+
+	wantErr := errors.New("dial failed")
+	if _, err := String(nil, wantErr); err != wantErr {
+		t.Errorf("wanted request error passed through got %v", err)
+	}
+}
+
+// TestMaxBodySizeRetry checks that retry hook reads limited body of every attempt
+func TestMaxBodySizeRetry(t *testing.T) {
+	attempts := 0
+	c := New(false).SetMaxBodySize(64).SetTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		attempts++
+		return &http.Response{
+			StatusCode:    http.StatusOK,
+			ContentLength: -1,
+			Body:          io.NopCloser(strings.NewReader(strings.Repeat("a", 1<<20))),
+		}, nil
+	}))
+
+	var read []int
+	ho := NewHTTPOptions().RetryHook(func(req *http.Request, res *http.Response, hc *http.Client, err error) (*http.Response, error) {
+		for range 2 {
+			if err != nil {
+				return nil, err
+			}
+			b, err := io.ReadAll(res.Body)
+			res.Body.Close()
+			read = append(read, len(b))
+			if !errors.Is(err, ErrBodyTooLarge) {
+				return nil, fmt.Errorf("wanted ErrBodyTooLarge got %v", err)
+			}
+			res, err = hc.Do(req)
+		}
+		return res, err
+	})
+	res, err := c.Get(context.Background(), "http://example.com", ho)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if attempts != 3 || len(read) != 2 || read[0] != 64 || read[1] != 64 {
+		t.Fatalf("wanted limited body of every attempt got %v after %d attempts", read, attempts)
+	}
+}
@@ -0,0 +1,153 @@
+package httpx
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// BulkheadError is returned when bulkhead rejects the request either because
+// the wait queue of the host is full or the request waited longer than queue timeout.
+type BulkheadError struct {
+	Host     string
+	InFlight int
+	Queued   int
+	// Timeout is true when request was queued but didn't get the slot within queue timeout
+	Timeout bool
+}
+
+func (e BulkheadError) Error() string {
+	reason := "queue full"
+	if e.Timeout {
+		reason = "queue timeout"
+	}
+	return fmt.Sprintf(
+		"bulkhead rejected request: reason=%s, host=%s, in_flight=%d, queued=%d",
+		reason,
+		e.Host,
+		e.InFlight,
+		e.Queued,
+	)
+}
+
+// BulkheadStats is snapshot of in-flight and queued requests for single host.
+type BulkheadStats struct {
+	InFlight int
+	Queued   int
+}
+
+// Bulkhead limits concurrent requests per host so one slow dependency
+// can not absorb all the goroutines of the service.
+//
+// Requests over the concurrency limit wait in bounded queue, if queue is full
+// or request waits longer than queue timeout [BulkheadError] is returned.
+// Slot is held until the response body is read till EOF or closed.
+type Bulkhead struct {
+	mu            sync.Mutex
+	hosts         map[string]*hostBulkhead
+	maxConcurrent int
+	maxQueue      int
+	queueTimeout  time.Duration
+}
+
+type hostBulkhead struct {
+	sem    chan struct{}
+	queued int // guarded by Bulkhead.mu
+}
+
+// NewBulkhead returns bulkhead which allows maxConcurrent in-flight requests per host
+// and maxQueue waiting requests per host. If queueTimeout is zero or negative
+// queued requests will wait until request context is done.
+func NewBulkhead(maxConcurrent, maxQueue int, queueTimeout time.Duration) *Bulkhead {
+	if maxConcurrent <= 0 {
+		maxConcurrent = 1
+	}
+	if maxQueue < 0 {
+		maxQueue = 0
+	}
+	return &Bulkhead{
+		hosts:         make(map[string]*hostBulkhead),
+		maxConcurrent: maxConcurrent,
+		maxQueue:      maxQueue,
+		queueTimeout:  queueTimeout,
+	}
+}
+
+// Acquire acquires slot for host and returns release func which must be called once
+// request is completed. Release func is safe to call multiple times.
+func (b *Bulkhead) Acquire(ctx context.Context, host string) (func(), error) {
+	b.mu.Lock()
+	h, ok := b.hosts[host]
+	if !ok {
+		h = &hostBulkhead{sem: make(chan struct{}, b.maxConcurrent)}
+		b.hosts[host] = h
+	}
+	select {
+	case h.sem <- struct{}{}:
+		b.mu.Unlock()
+		return h.release(), nil
+	default:
+	}
+	if h.queued >= b.maxQueue {
+		err := BulkheadError{Host: host, InFlight: len(h.sem), Queued: h.queued}
+		b.mu.Unlock()
+		return nil, err
+	}
+	h.queued++
+	b.mu.Unlock()
+
+	defer func() {
+		b.mu.Lock()
+		h.queued--
+		b.mu.Unlock()
+	}()
+
+	var timeout <-chan time.Time
+	if b.queueTimeout > 0 {
+		t := time.NewTimer(b.queueTimeout)
+		defer t.Stop()
+		timeout = t.C
+	}
+
+	select {
+	case h.sem <- struct{}{}:
+		return h.release(), nil
+	case <-timeout:
+		b.mu.Lock()
+		err := BulkheadError{Host: host, InFlight: len(h.sem), Queued: h.queued, Timeout: true}
+		b.mu.Unlock()
+		return nil, err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Stats returns in-flight and queued requests count for every host seen by bulkhead.
+func (b *Bulkhead) Stats() map[string]BulkheadStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	m := make(map[string]BulkheadStats, len(b.hosts))
+	for host, h := range b.hosts {
+		m[host] = BulkheadStats{InFlight: len(h.sem), Queued: h.queued}
+	}
+	return m
+}
+
+// HostStats returns in-flight and queued requests count for host.
+func (b *Bulkhead) HostStats(host string) BulkheadStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	h, ok := b.hosts[host]
+	if !ok {
+		return BulkheadStats{}
+	}
+	return BulkheadStats{InFlight: len(h.sem), Queued: h.queued}
+}
+
+func (h *hostBulkhead) release() func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() { <-h.sem })
+	}
+}
@@ -0,0 +1,76 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func okResponse() *http.Response {
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     make(http.Header),
+		Body:       io.NopCloser(strings.NewReader("OK")),
+	}
+}
+
+// TestBulkhead checks that requests over the limit are queued and rejected
+func TestBulkhead(t *testing.T) {
+	b := NewBulkhead(1, 1, 50*time.Millisecond)
+	c := New(false).SetBulkhead(b).SetTransport(roundTripFunc(func(*http.Request) (*http.Response, error) {
+		return okResponse(), nil
+	}))
+
+	// first request holds the slot until body is closed
+	res, err := c.Get(context.Background(), "http://example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := b.HostStats("example.com"); got.InFlight != 1 {
+		t.Fatalf("wanted 1 in-flight request got %d", got.InFlight)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.Get(context.Background(), "http://example.com", nil)
+		done <- err
+	}()
+
+	// wait for second request to be queued, third must be rejected
+	for b.HostStats("example.com").Queued != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	_, err = c.Get(context.Background(), "http://example.com", nil)
+	var bhErr BulkheadError
+	if !errors.As(err, &bhErr) || bhErr.Timeout {
+		t.Fatalf("wanted queue full error got %v", err)
+	}
+
+	// queued request must time out while slot is still held
+	err = <-done
+	if !errors.As(err, &bhErr) || !bhErr.Timeout {
+		t.Fatalf("wanted queue timeout error got %v", err)
+	}
+
+	res.Body.Close()
+	if got := b.HostStats("example.com"); got.InFlight != 0 || got.Queued != 0 {
+		t.Fatalf("wanted empty bulkhead got %+v", got)
+	}
+
+	// other hosts are not affected
+	res, err = c.Get(context.Background(), "http://example.org", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+}
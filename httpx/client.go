@@ -6,6 +6,7 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptrace"
+	"time"
 )
 
 // Change this to desired user agent header
@@ -13,17 +14,32 @@ import (
 const HeaderUserAgent = "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/134.0.0.0 Safari/537.36"
 
 type Client struct {
-	client *http.Client
-	tracer *httptrace.ClientTrace
-	trace  bool
+	client    *http.Client
+	transport http.RoundTripper
+	tracer    *httptrace.ClientTrace
+	bulkhead  *Bulkhead
+	metrics   *Metrics
+	traceOpts TraceOptions
+	trace     bool
+	// maxBodySize limits response bodies, zero is unlimited
+	maxBodySize int64
+	// bandwidth shared by all requests
+	uploadLimiter   *Limiter
+	downloadLimiter *Limiter
+	// life tracks requests in flight for shutdown
+	life *lifecycle
+	// name is passed to hooks in RequestInfo
+	name string
 }
 
 func New(trace bool) *Client {
-	return (&Client{
+	c := &Client{
 		client: &http.Client{},
 		trace:  trace,
-		tracer: getTracer(),
-	}).SetTransport(defaultTransport)
+		life:   newLifecycle(),
+	}
+	c.client.Transport = &clientTransport{c: c}
+	return c.SetTransport(defaultTransport)
 }
 
 // SetTransport set the httptransport,
@@ -31,11 +47,25 @@ func New(trace bool) *Client {
 // default transport will be used.
 func (c *Client) SetTransport(t http.RoundTripper) *Client {
 	if t != nil {
-		c.client.Transport = t
+		c.transport = t
 	}
 	return c
 }
 
+// Transport returns the configured transport without client policies such as bulkhead
+// and metrics, it can be used for protocols which take over the connection.
+func (c *Client) Transport() http.RoundTripper {
+	return c.transport
+}
+
+// SetBulkhead limits concurrent requests per host with provided bulkhead.
+// Every attempt including the ones performed by retry hook acquires the slot,
+// so excess requests will be rejected with [BulkheadError].
+func (c *Client) SetBulkhead(b *Bulkhead) *Client {
+	c.bulkhead = b
+	return c
+}
+
 // DisableRedirect disable the redirects in http.Client.
 // By default redirect are not disabled and
 // follows upto configured redirects in http client.
@@ -53,6 +83,12 @@ func (c *Client) SetCookieJar(jar http.CookieJar) *Client {
 	return c
 }
 
+// SetMetrics records runtime metrics of every attempt into m.
+func (c *Client) SetMetrics(m *Metrics) *Client {
+	c.metrics = m
+	return c
+}
+
 // SetTracer replace default tracer with your own implementation.
 func (c *Client) SetTracer(tracer *httptrace.ClientTrace) *Client {
 	if tracer != nil {
@@ -61,6 +97,14 @@ func (c *Client) SetTracer(tracer *httptrace.ClientTrace) *Client {
 	return c
 }
 
+// SetTraceOptions enables tracing and configures the default [log/slog] based tracer.
+// It has no effect on the tracer provided with [Client.SetTracer].
+func (c *Client) SetTraceOptions(opts TraceOptions) *Client {
+	c.traceOpts = opts
+	c.trace = true
+	return c
+}
+
 // Get is http get method
 func (c *Client) Get(ctx context.Context, uri string, ho *HTTPOptions) (*http.Response, error) {
 	return c.Exec(ctx, http.MethodGet, uri, nil, ho)
@@ -106,6 +150,49 @@ func (c *Client) Delete(ctx context.Context, uri string, ho *HTTPOptions) (*http
 	return c.Exec(ctx, http.MethodDelete, uri, nil, ho)
 }
 
+// NewRequest builds the request as [Client.Exec] sends it, with default and optional headers,
+// queries, body compression and request hook applied. Upload transfer and tracing are only
+// applied by Exec. It can be used for inspecting the final request e.g. rendering it with [Curl].
+func (c *Client) NewRequest(
+	ctx context.Context,
+	method, uri string,
+	body io.Reader,
+	ho *HTTPOptions,
+) (*http.Request, error) {
+	if ho == nil {
+		ho = &HTTPOptions{}
+	}
+
+	// initiate request with context
+	req, err := http.NewRequestWithContext(ctx, method, uri, body)
+	if err != nil {
+		return nil, err
+	}
+	// initiate request header for general uses
+	req.Header.Set("User-Agent", HeaderUserAgent)
+
+	// set all optional headers
+	for k, v := range ho.headers {
+		req.Header.Set(k, v)
+	}
+
+	// set all optional queries
+	q := req.URL.Query()
+	for k, v := range ho.queries {
+		q.Set(k, v)
+	}
+	req.URL.RawQuery = q.Encode()
+
+	// body is compressed before request hook so signers see the payload being sent
+	compressBody(req, ho.compress)
+	if ho.requestHook != nil {
+		if err := ho.requestHook(req.Context(), c.requestInfo(req.Context()), req); err != nil {
+			return nil, fmt.Errorf("failed to execute request hook: %w", err)
+		}
+	}
+	return req, nil
+}
+
 // Exec performs the HTTP request with the given method, uri, and options.
 //
 // Hook execution order:
@@ -128,54 +215,69 @@ func (c *Client) Delete(ctx context.Context, uri string, ho *HTTPOptions) (*http
 //     any post-processing logic inside the retryHook implementation.
 //
 // This ensures hooks remain predictable and prevents accidental multiple
-// reads of the response body.
+// reads of the response body. Hooks set with v2 setters such as
+// [HTTPOptions.RequestHookV2] replace the plain ones and run in the same order.
+//
+// Request is in flight until its response body is read till EOF or closed, requests
+// started after [Client.Shutdown] fail with [ErrClientClosed].
 func (c *Client) Exec(
 	ctx context.Context,
 	method, uri string,
 	body io.Reader,
 	ho *HTTPOptions,
 ) (*http.Response, error) {
-	// if trace is available
-	if c.trace {
-		ctx = httptrace.WithClientTrace(ctx, c.tracer)
-	}
+	return c.track(ctx, func(ctx context.Context) (*http.Response, error) {
+		return c.exec(ctx, method, uri, body, ho)
+	})
+}
 
+func (c *Client) exec(
+	ctx context.Context,
+	method, uri string,
+	body io.Reader,
+	ho *HTTPOptions,
+) (*http.Response, error) {
 	if ho == nil {
 		ho = &HTTPOptions{}
 	}
+	ctx = withExecState(ctx, &execState{
+		start:       time.Now(),
+		name:        c.name,
+		timings:     ho.timings,
+		redirects:   ho.redirects,
+		maxBodySize: c.bodyLimit(ho),
+	})
 
-	// initiate request with context
-	req, err := http.NewRequestWithContext(ctx, method, uri, body)
+	req, err := c.NewRequest(ctx, method, uri, body, ho)
 	if err != nil {
 		return nil, err
 	}
-	// initiate request header for general uses
-	req.Header.Set("User-Agent", HeaderUserAgent)
 
-	// set all optional headers
-	for k, v := range ho.headers {
-		req.Header.Set(k, v)
-	}
-
-	// set all optional queries
-	q := req.URL.Query()
-	for k, v := range ho.queries {
-		q.Set(k, v)
-	}
-	req.URL.RawQuery = q.Encode()
-	if ho.requestHook != nil {
-		if err := ho.requestHook(req); err != nil {
-			return nil, fmt.Errorf("failed to execute request hook: %w", err)
+	// if trace is available
+	if c.trace {
+		tracer := c.tracer
+		if tracer == nil {
+			tracer = getTracer(c.traceOpts, req)
 		}
+		req = req.WithContext(httptrace.WithClientTrace(req.Context(), tracer))
 	}
+	c.wrapUpload(req, ho.upload)
 
 	res, err := c.client.Do(req)
 	if ho.retryHook != nil {
-		return ho.retryHook(req, res, c.client, err)
+		res, err = ho.retryHook(req.Context(), c.requestInfo(req.Context()), req, res, c.client, err)
+		if err == nil {
+			c.wrapDownload(ctx, res, ho.download)
+		}
+		return res, err
+	}
+	if err != nil {
+		return nil, err
 	}
+	c.wrapDownload(ctx, res, ho.download)
 
 	if ho.responseHook != nil {
-		if err := ho.responseHook(req, res); err != nil {
+		if err := ho.responseHook(req.Context(), c.requestInfo(req.Context()), req, res); err != nil {
 			return nil, fmt.Errorf("failed to execute response hook: %w", err)
 		}
 	}
@@ -0,0 +1,137 @@
+package httpx
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// Encoder compresses request bodies, it can be implemented for encodings such as zstd
+// or brotli which are not in standard library.
+type Encoder interface {
+	// Encoding is the Content-Encoding header value
+	Encoding() string
+	// NewWriter returns writer compressing into w, Close must flush the remaining data
+	// without closing w.
+	NewWriter(w io.Writer) (io.WriteCloser, error)
+}
+
+// GzipEncoder compresses with gzip, zero Level is the default compression.
+type GzipEncoder struct {
+	Level int
+}
+
+func (GzipEncoder) Encoding() string {
+	return "gzip"
+}
+
+func (e GzipEncoder) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	if e.Level == 0 {
+		return gzip.NewWriter(w), nil
+	}
+	return gzip.NewWriterLevel(w, e.Level)
+}
+
+// DeflateEncoder compresses with deflate which is zlib format in http as specified
+// by RFC 9110, zero Level is the default compression.
+type DeflateEncoder struct {
+	Level int
+}
+
+func (DeflateEncoder) Encoding() string {
+	return "deflate"
+}
+
+func (e DeflateEncoder) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	if e.Level == 0 {
+		return zlib.NewWriter(w), nil
+	}
+	return zlib.NewWriterLevel(w, e.Level)
+}
+
+// compression is the request body compression set with [HTTPOptions.Compress]
+type compression struct {
+	enc     Encoder
+	minSize int64
+}
+
+// Compress compresses request body with enc while it's being sent and sets Content-Encoding
+// header. Bodies with known size below minSize are sent as is, bodies of unknown size are
+// always compressed. Requests which already have Content-Encoding are not compressed.
+// Compressed body is sent with chunked transfer encoding as its size is not known upfront.
+// Body is compressed before request hook runs, so signing hooks sign the compressed payload
+// and [HTTPOptions.Upload] reports the compressed bytes.
+func (ho *HTTPOptions) Compress(enc Encoder, minSize int64) *HTTPOptions {
+	ho.compress = &compression{enc: enc, minSize: minSize}
+	return ho
+}
+
+// compressBody replaces request body and GetBody with the compressed streams
+func compressBody(req *http.Request, cmp *compression) {
+	if cmp == nil || cmp.enc == nil || req.Body == nil || req.Body == http.NoBody {
+		return
+	}
+	if req.Header.Get("Content-Encoding") != "" || req.ContentLength > 0 && req.ContentLength < cmp.minSize {
+		return
+	}
+
+	req.Body = &compressReader{src: req.Body, enc: cmp.enc}
+	if getBody := req.GetBody; getBody != nil {
+		req.GetBody = func() (io.ReadCloser, error) {
+			rc, err := getBody()
+			if err != nil {
+				return nil, err
+			}
+			return &compressReader{src: rc, enc: cmp.enc}, nil
+		}
+	}
+	req.ContentLength = -1
+	req.Header.Del("Content-Length")
+	req.Header.Set("Content-Encoding", cmp.enc.Encoding())
+}
+
+// compressReader compresses src through pipe, compression starts with first Read
+// so replays which are never sent don't spawn goroutine.
+type compressReader struct {
+	src  io.ReadCloser
+	enc  Encoder
+	once sync.Once
+	pr   *io.PipeReader
+}
+
+func (r *compressReader) start() {
+	pr, pw := io.Pipe()
+	r.pr = pr
+	go func() {
+		zw, err := r.enc.NewWriter(pw)
+		if err == nil {
+			_, err = io.Copy(zw, r.src)
+			if cerr := zw.Close(); err == nil {
+				err = cerr
+			}
+		}
+		r.src.Close()
+		pw.CloseWithError(err)
+	}()
+}
+
+func (r *compressReader) Read(p []byte) (int, error) {
+	r.once.Do(r.start)
+	if r.pr == nil {
+		// closed before first read
+		return 0, io.ErrClosedPipe
+	}
+	return r.pr.Read(p)
+}
+
+// Close stops the compression, reading of src is aborted once pipe is closed.
+func (r *compressReader) Close() error {
+	started := true
+	r.once.Do(func() { started = false })
+	if !started {
+		return r.src.Close()
+	}
+	return r.pr.Close()
+}
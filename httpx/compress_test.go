@@ -0,0 +1,76 @@
+package httpx
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCompress(t *testing.T) {
+	var encodings, bodies []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body io.Reader = r.Body
+		switch r.Header.Get("Content-Encoding") {
+		case "gzip":
+			zr, err := gzip.NewReader(r.Body)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			body = zr
+		case "deflate":
+			zr, err := zlib.NewReader(r.Body)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			body = zr
+		}
+		b, _ := io.ReadAll(body)
+		encodings = append(encodings, r.Header.Get("Content-Encoding"))
+		bodies = append(bodies, string(b))
+		if len(bodies) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	t.Cleanup(ts.Close)
+
+	payload := strings.Repeat("compress me ", 1000)
+	retry := func(req *http.Request, res *http.Response, hc *http.Client, err error) (*http.Response, error) {
+		if err == nil && res.StatusCode == http.StatusOK {
+			return res, nil
+		}
+		res.Body.Close()
+		req.Body, _ = req.GetBody()
+		return hc.Do(req)
+	}
+	c := New(false)
+	ho := NewHTTPOptions().Compress(GzipEncoder{}, 1024).RetryHook(retry)
+	res, err := c.Post(context.Background(), ts.URL, strings.NewReader(payload), ho)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	ho = NewHTTPOptions().Compress(DeflateEncoder{}, 1024)
+	for _, body := range []string{payload, "small"} {
+		res, err := c.Post(context.Background(), ts.URL, strings.NewReader(body), ho)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+	}
+
+	wantEnc := []string{"gzip", "gzip", "deflate", ""}
+	wantBody := []string{payload, payload, payload, "small"}
+	for i := range wantEnc {
+		if encodings[i] != wantEnc[i] || bodies[i] != wantBody[i] {
+			t.Errorf("request %d: wanted encoding %q got %q with body length %d", i, wantEnc[i], encodings[i], len(bodies[i]))
+		}
+	}
+}
@@ -0,0 +1,432 @@
+package httpx
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+)
+
+// Curl renders the request as shell safe curl command. Request body is read through
+// [net/http.Request.GetBody] when available, otherwise it's buffered and replaced so
+// request can still be sent.
+func Curl(req *http.Request) (string, error) {
+	var b strings.Builder
+	b.WriteString("curl")
+
+	body, err := curlBody(req)
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case req.Method == http.MethodHead:
+		b.WriteString(" --head")
+	case req.Method == http.MethodGet && body == nil:
+	case req.Method == http.MethodPost && body != nil:
+	default:
+		b.WriteString(" -X " + shellQuote(req.Method))
+	}
+
+	for _, k := range slices.Sorted(maps.Keys(req.Header)) {
+		for _, v := range req.Header[k] {
+			b.WriteString(" -H " + shellQuote(k+": "+v))
+		}
+	}
+	if req.Host != "" && req.Host != req.URL.Host {
+		b.WriteString(" -H " + shellQuote("Host: "+req.Host))
+	}
+	if body != nil {
+		b.WriteString(" --data-binary " + shellQuote(string(body)))
+	}
+	b.WriteString(" " + shellQuote(req.URL.String()))
+	return b.String(), nil
+}
+
+func curlBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	if req.GetBody != nil {
+		rc, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return io.ReadAll(rc)
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	return body, nil
+}
+
+// shellQuote quotes s for POSIX shell, strings with non printable characters
+// are quoted with ANSI-C quoting.
+func shellQuote(s string) string {
+	if s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' ||
+			strings.ContainsRune("-_./:=@,+%", r))
+	}) < 0 {
+		return s
+	}
+	printable := strings.IndexFunc(s, func(r rune) bool {
+		return r < 0x20 && r != '\t' || r == 0x7f || r == 0xfffd
+	}) < 0
+	if printable {
+		return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+	}
+	var b strings.Builder
+	b.WriteString("$'")
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\' || c == '\'':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c == '\n':
+			b.WriteString(`\n`)
+		case c == '\r':
+			b.WriteString(`\r`)
+		case c == '\t':
+			b.WriteString(`\t`)
+		case c < 0x20 || c >= 0x7f:
+			fmt.Fprintf(&b, `\x%02x`, c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('\'')
+	return b.String()
+}
+
+// CurlCommand is the parsed curl command line.
+type CurlCommand struct {
+	Method  string
+	URL     string
+	Body    []byte
+	Options *HTTPOptions
+	// Insecure is set for -k, per request TLS settings are not supported by [HTTPOptions]
+	// so it must be applied on the transport, note the default transport skips verification.
+	Insecure bool
+	// Compressed is set for --compressed, transport transparently requests and decompresses
+	// gzip responses as long as Accept-Encoding header is not set explicitly.
+	Compressed bool
+}
+
+// ErrUnsupportedCurlFlag is returned by [ParseCurl] for flags it doesn't understand.
+var ErrUnsupportedCurlFlag = errors.New("unsupported curl flag")
+
+// curlIgnoredFlags are the flags without argument which don't change the request.
+var curlIgnoredFlags = map[string]bool{
+	"-s": true, "--silent": true, "-S": true, "--show-error": true, "-v": true, "--verbose": true,
+	"-i": true, "--include": true, "-L": true, "--location": true, "-f": true, "--fail": true,
+	"-g": true, "--globoff": true,
+}
+
+// curlValueFlags are the flags taking argument.
+var curlValueFlags = map[string]bool{
+	"-X": true, "--request": true, "-H": true, "--header": true, "-d": true, "--data": true,
+	"--data-ascii": true, "--data-raw": true, "--data-binary": true, "-u": true, "--user": true,
+	"--url": true,
+}
+
+// ParseCurl parses curl command line supporting -X, -H, -d, --data-raw, --data-binary,
+// -u, -k, -I and --compressed flags. Data starting with @ is read from the file. Short
+// flags can be combined such as -sSL and argument can be attached such as -XPOST.
+// Header names are canonicalized and values of repeated header are joined with comma,
+// or semicolon for Cookie.
+func ParseCurl(cmd string) (*CurlCommand, error) {
+	args, err := shellSplit(cmd)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 0 || args[0] != "curl" {
+		return nil, errors.New("curl: command must start with curl")
+	}
+	args = splitShortFlags(args)
+
+	cc := &CurlCommand{Options: NewHTTPOptions()}
+	var data [][]byte
+	var form bool
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+		// value returns argument of arg
+		value := func() (string, error) {
+			if i+1 >= len(args) {
+				return "", fmt.Errorf("curl: missing value for %s", arg)
+			}
+			i++
+			return args[i], nil
+		}
+
+		switch {
+		case arg == "-X" || arg == "--request":
+			v, err := value()
+			if err != nil {
+				return nil, err
+			}
+			cc.Method = v
+		case arg == "-H" || arg == "--header":
+			v, err := value()
+			if err != nil {
+				return nil, err
+			}
+			k, hv, ok := strings.Cut(v, ":")
+			if !ok {
+				return nil, fmt.Errorf("curl: invalid header %q", v)
+			}
+			k, hv = http.CanonicalHeaderKey(strings.TrimSpace(k)), strings.TrimSpace(hv)
+			if prev, ok := cc.Options.headers[k]; ok {
+				sep := ", "
+				if k == "Cookie" {
+					sep = "; "
+				}
+				hv = prev + sep + hv
+			}
+			cc.Options.Header(k, hv)
+		case arg == "-d" || arg == "--data" || arg == "--data-ascii" ||
+			arg == "--data-raw" || arg == "--data-binary":
+			v, err := value()
+			if err != nil {
+				return nil, err
+			}
+			d := []byte(v)
+			if arg != "--data-raw" && strings.HasPrefix(v, "@") {
+				if d, err = os.ReadFile(v[1:]); err != nil {
+					return nil, err
+				}
+				if arg != "--data-binary" {
+					d = bytes.ReplaceAll(bytes.ReplaceAll(d, []byte("\r"), nil), []byte("\n"), nil)
+				}
+			}
+			data = append(data, d)
+			form = form || arg != "--data-binary"
+		case arg == "-u" || arg == "--user":
+			v, err := value()
+			if err != nil {
+				return nil, err
+			}
+			cc.Options.Header("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(v)))
+		case arg == "-k" || arg == "--insecure":
+			cc.Insecure = true
+		case arg == "--compressed":
+			cc.Compressed = true
+		case arg == "-I" || arg == "--head":
+			cc.Method = http.MethodHead
+		case arg == "--url":
+			v, err := value()
+			if err != nil {
+				return nil, err
+			}
+			cc.URL = v
+		case curlIgnoredFlags[arg]:
+		case strings.HasPrefix(arg, "-") && len(arg) > 1:
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedCurlFlag, arg)
+		default:
+			cc.URL = arg
+		}
+	}
+	if cc.URL == "" {
+		return nil, errors.New("curl: missing url")
+	}
+
+	if len(data) > 0 {
+		cc.Body = bytes.Join(data, []byte("&"))
+		if cc.Method == "" {
+			cc.Method = http.MethodPost
+		}
+		if _, ok := cc.Options.headers["Content-Type"]; form && !ok {
+			cc.Options.Header("Content-Type", "application/x-www-form-urlencoded")
+		}
+	}
+	if cc.Method == "" {
+		cc.Method = http.MethodGet
+	}
+	return cc, nil
+}
+
+// splitShortFlags splits combined short flags such as -sSL into single flags, rest of the
+// argument after short flag taking argument is its value such as -XPOST.
+func splitShortFlags(args []string) []string {
+	out := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if curlValueFlags[arg] && i+1 < len(args) {
+			out = append(out, arg, args[i+1])
+			i++
+			continue
+		}
+		if len(arg) <= 2 || arg[0] != '-' || arg[1] == '-' {
+			out = append(out, arg)
+			continue
+		}
+		for j := 1; j < len(arg); j++ {
+			flag := "-" + arg[j:j+1]
+			out = append(out, flag)
+			if curlValueFlags[flag] {
+				if j+1 < len(arg) {
+					out = append(out, arg[j+1:])
+				} else if i+1 < len(args) {
+					i++
+					out = append(out, args[i])
+				}
+				break
+			}
+		}
+	}
+	return out
+}
+
+// ExecCurl parses curl command line with [ParseCurl] and executes the equivalent request.
+// ho can be used for setting hooks, headers and queries are merged with the ones of command.
+func (c *Client) ExecCurl(ctx context.Context, cmd string, ho *HTTPOptions) (*http.Response, error) {
+	cc, err := ParseCurl(cmd)
+	if err != nil {
+		return nil, err
+	}
+	if ho != nil {
+		merged := *ho
+		merged.headers = maps.Clone(cc.Options.headers)
+		maps.Copy(merged.headers, ho.headers)
+		merged.queries = ho.queries
+		cc.Options = &merged
+	}
+	var body io.Reader
+	if cc.Body != nil {
+		body = bytes.NewReader(cc.Body)
+	}
+	return c.Exec(ctx, cc.Method, cc.URL, body, cc.Options)
+}
+
+// shellSplit splits command line into arguments following POSIX shell quoting rules
+// along with ANSI-C $'...' quoting.
+func shellSplit(s string) ([]string, error) {
+	var args []string
+	var cur strings.Builder
+	inArg := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\':
+			if i+1 < len(s) {
+				i++
+				// backslash newline is line continuation
+				if s[i] != '\n' {
+					cur.WriteByte(s[i])
+					inArg = true
+				}
+			}
+		case c == '\'':
+			end := strings.IndexByte(s[i+1:], '\'')
+			if end < 0 {
+				return nil, errors.New("curl: unterminated single quote")
+			}
+			cur.WriteString(s[i+1 : i+1+end])
+			i += end + 1
+			inArg = true
+		case c == '$' && i+1 < len(s) && s[i+1] == '\'':
+			n, err := ansiQuoted(s[i+2:], &cur)
+			if err != nil {
+				return nil, err
+			}
+			i += n + 2
+			inArg = true
+		case c == '"':
+			i++
+			for ; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' && i+1 < len(s) && strings.IndexByte("$`\"\\\n", s[i+1]) >= 0 {
+					i++
+					if s[i] == '\n' {
+						continue
+					}
+				}
+				cur.WriteByte(s[i])
+			}
+			if i >= len(s) {
+				return nil, errors.New("curl: unterminated double quote")
+			}
+			inArg = true
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			if inArg {
+				args = append(args, cur.String())
+				cur.Reset()
+				inArg = false
+			}
+		default:
+			cur.WriteByte(c)
+			inArg = true
+		}
+	}
+	if inArg {
+		args = append(args, cur.String())
+	}
+	return args, nil
+}
+
+// ansiQuoted decodes $'...' quoted string till closing quote and returns consumed bytes.
+func ansiQuoted(s string, out *strings.Builder) (int, error) {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '\'' {
+			return i, nil
+		}
+		if c != '\\' || i+1 >= len(s) {
+			out.WriteByte(c)
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n':
+			out.WriteByte('\n')
+		case 'r':
+			out.WriteByte('\r')
+		case 't':
+			out.WriteByte('\t')
+		case 'x':
+			var b byte
+			n := 0
+			for ; n < 2 && i+1 < len(s); n++ {
+				d := unhex(s[i+1])
+				if d < 0 {
+					break
+				}
+				b = b<<4 | byte(d)
+				i++
+			}
+			if n == 0 {
+				out.WriteString(`\x`)
+				continue
+			}
+			out.WriteByte(b)
+		default:
+			out.WriteByte(s[i])
+		}
+	}
+	return 0, errors.New("curl: unterminated ansi-c quote")
+}
+
+func unhex(c byte) int {
+	switch {
+	case c >= '0' && c <= '9':
+		return int(c - '0')
+	case c >= 'a' && c <= 'f':
+		return int(c-'a') + 10
+	case c >= 'A' && c <= 'F':
+		return int(c-'A') + 10
+	}
+	return -1
+}
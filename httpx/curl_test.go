@@ -0,0 +1,129 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"io"
+	"maps"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestCurlRoundTrip renders request as curl command and parses it back
+func TestCurlRoundTrip(t *testing.T) {
+	body := "{\"name\": \"it's\"}\n\x00"
+	ho := NewHTTPOptions().
+		Header("Content-Type", "application/json").
+		Query("q", "a b").
+		RequestHook(func(r *http.Request) error {
+			r.Header.Set("X-Signed", "yes")
+			return nil
+		})
+	req, err := New(false).NewRequest(
+		context.Background(),
+		http.MethodPut,
+		"https://example.com/users?id=1",
+		strings.NewReader(body),
+		ho,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd, err := Curl(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cc, err := ParseCurl(cmd)
+	if err != nil {
+		t.Fatalf("failed to parse %s: %v", cmd, err)
+	}
+	if cc.Method != http.MethodPut || cc.URL != "https://example.com/users?id=1&q=a+b" {
+		t.Errorf("unexpected method or url %s %s", cc.Method, cc.URL)
+	}
+	if string(cc.Body) != body {
+		t.Errorf("wanted body %q got %q", body, cc.Body)
+	}
+	want := map[string]string{
+		"Content-Type": "application/json",
+		"User-Agent":   HeaderUserAgent,
+		"X-Signed":     "yes",
+	}
+	if !reflect.DeepEqual(cc.Options.headers, want) {
+		t.Errorf("wanted headers %v got %v", want, cc.Options.headers)
+	}
+}
+
+func TestParseCurl(t *testing.T) {
+	var got *http.Request
+	var gotBody string
+	c := New(false).SetTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		b, _ := io.ReadAll(req.Body)
+		got, gotBody = req, string(b)
+		return okResponse(), nil
+	}))
+
+	cmd := `curl -k --compressed -u 'user:pa ss' \
+		-H "X-Quote: say \"hi\"" -d a=1 --data-raw "b=2" https://example.com/form`
+	res, err := c.ExecCurl(context.Background(), cmd, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	if got.Method != http.MethodPost || gotBody != "a=1&b=2" {
+		t.Errorf("wanted POST with a=1&b=2 got %s with %q", got.Method, gotBody)
+	}
+	if u, p, _ := got.BasicAuth(); u != "user" || p != "pa ss" {
+		t.Errorf("unexpected basic auth %s:%s", u, p)
+	}
+	if v := got.Header.Get("X-Quote"); v != `say "hi"` {
+		t.Errorf("unexpected header %q", v)
+	}
+	if v := got.Header.Get("Content-Type"); v != "application/x-www-form-urlencoded" {
+		t.Errorf("unexpected content type %q", v)
+	}
+
+	if _, err := ParseCurl("curl --proxy http://proxy https://example.com"); err == nil {
+		t.Error("wanted error for unsupported flag")
+	}
+
+	// browsers copy lowercase headers, explicit content type is kept for data
+	cc, err := ParseCurl(`curl 'https://example.com/api' -H 'content-type: application/json' ` +
+		`-H 'accept: a/b' -H 'Accept: c/d' -H 'cookie: a=1' -H 'Cookie: b=2' --data-raw '{}'`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"Content-Type": "application/json", "Accept": "a/b, c/d", "Cookie": "a=1; b=2"}
+	if !maps.Equal(cc.Options.headers, want) {
+		t.Errorf("wanted headers %v got %v", want, cc.Options.headers)
+	}
+
+	// combined short flags
+	tests := []struct {
+		cmd      string
+		method   string
+		insecure bool
+	}{
+		{"curl -sS https://example.com", http.MethodGet, false},
+		{"curl -sL https://example.com", http.MethodGet, false},
+		{"curl -kv https://example.com", http.MethodGet, true},
+		{"curl -vkXPUT https://example.com", http.MethodPut, true},
+		{"curl -sX DELETE https://example.com", http.MethodDelete, false},
+	}
+	for _, tt := range tests {
+		cc, err := ParseCurl(tt.cmd)
+		if err != nil {
+			t.Errorf("%s: %v", tt.cmd, err)
+			continue
+		}
+		if cc.Method != tt.method || cc.Insecure != tt.insecure || cc.URL != "https://example.com" {
+			t.Errorf("%s: unexpected command %+v", tt.cmd, cc)
+		}
+	}
+	if _, err := ParseCurl("curl -kx http://proxy https://example.com"); !errors.Is(err, ErrUnsupportedCurlFlag) {
+		t.Errorf("wanted unsupported flag error for combined -x got %v", err)
+	}
+}
@@ -0,0 +1,3 @@
+// Package download contains download manager which resumes partial files with range requests
+// and downloads large files in parallel chunks
+package download
@@ -0,0 +1,465 @@
+package download
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"collections/httpx"
+	"collections/httpx/hooks"
+	"collections/workerpool"
+)
+
+const (
+	defaultConcurrency = 4
+	defaultChunkSize   = 8 * 1024 * 1024
+	defaultMaxRetries  = 5
+	copyBufferSize     = 32 * 1024
+)
+
+// ErrResourceChanged is returned when the remote file changed while it was being downloaded,
+// partial file is discarded so next download starts from scratch.
+var ErrResourceChanged = errors.New("download: remote resource changed")
+
+// StatusError is returned when server responds with unexpected status.
+type StatusError struct {
+	StatusCode int
+	URL        string
+}
+
+func (e StatusError) Error() string {
+	return fmt.Sprintf("download: unexpected response status=%d, url=%s", e.StatusCode, e.URL)
+}
+
+// temporary reports whether request might succeed on retry
+func (e StatusError) temporary() bool {
+	return e.StatusCode >= 500 || e.StatusCode == http.StatusTooManyRequests ||
+		e.StatusCode == http.StatusRequestTimeout
+}
+
+// Progress is reported while file is downloaded.
+type Progress struct {
+	// Downloaded bytes including the ones resumed from partial file
+	Downloaded int64
+	// Total is size of file, -1 if server didn't send Content-Length
+	Total int64
+}
+
+// Options configures the [Downloader].
+type Options struct {
+	// HTTPOptions returns options for every request, it can be used for setting auth headers.
+	// Range, If-Range and Accept-Encoding headers are set by downloader.
+	HTTPOptions func() *httpx.HTTPOptions
+	// Concurrency is number of chunks downloaded in parallel, default is 4
+	Concurrency int
+	// ChunkSize is size of single range request, default is 8MB
+	ChunkSize int64
+	// MaxRetries is maximum retries of single chunk before download fails, default is 5
+	MaxRetries int
+	// Backoff is used for wait time between chunk retries, default is equal jitter backoff
+	Backoff *hooks.BackoffWithJitter
+	// SHA256 is the expected hex encoded checksum of file. Repr-Digest header sent by server
+	// is verified as well.
+	SHA256 string
+	// Progress is called whenever bytes are written to the file. It's called from the download
+	// goroutines but never concurrently.
+	Progress func(Progress)
+}
+
+// Downloader downloads files into "<dst>.part" along with "<dst>.part.state" state file
+// which are used for resuming the download, file is renamed to dst once it's verified.
+type Downloader struct {
+	c    *httpx.Client
+	opts Options
+	// mu guards backoff which is not safe for concurrent use
+	mu sync.Mutex
+}
+
+func New(c *httpx.Client, opts Options) *Downloader {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = defaultConcurrency
+	}
+	if opts.ChunkSize <= 0 {
+		opts.ChunkSize = defaultChunkSize
+	}
+	if opts.MaxRetries <= 0 {
+		opts.MaxRetries = defaultMaxRetries
+	}
+	if opts.Backoff == nil {
+		opts.Backoff = hooks.NewBackoffWithJitter(0, 0, hooks.EqualJitter)
+	}
+	return &Downloader{c: c, opts: opts}
+}
+
+// remote is the metadata of remote file
+type remote struct {
+	size         int64
+	ranges       bool
+	etag         string
+	lastModified string
+	digests      map[string]string
+}
+
+// Download downloads uri into dst. If server advertises "Accept-Ranges: bytes" file is
+// downloaded in parallel chunks and interrupted download is resumed, otherwise it's
+// downloaded in single stream from the start. Size and checksums are verified before
+// file is moved to dst.
+func (d *Downloader) Download(ctx context.Context, uri, dst string) error {
+	rm, err := d.probe(ctx, uri)
+	if err != nil {
+		return err
+	}
+
+	part := dst + ".part"
+	statePath := part + ".state"
+	if rm.ranges {
+		err = d.parallel(ctx, uri, part, statePath, rm)
+	} else {
+		os.Remove(statePath)
+		err = d.single(ctx, uri, part, rm)
+	}
+	if errors.Is(err, ErrResourceChanged) {
+		os.Remove(part)
+		os.Remove(statePath)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := d.verify(part, rm); err != nil {
+		os.Remove(part)
+		os.Remove(statePath)
+		return err
+	}
+	if err := os.Rename(part, dst); err != nil {
+		return err
+	}
+	os.Remove(statePath)
+	return nil
+}
+
+// probe fetches the metadata with HEAD request, servers which doesn't allow HEAD
+// are downloaded in single stream.
+func (d *Downloader) probe(ctx context.Context, uri string) (*remote, error) {
+	res, err := d.c.Head(ctx, uri, d.httpOptions())
+	if err != nil {
+		return nil, err
+	}
+	res.Body.Close()
+
+	rm := &remote{size: -1}
+	switch {
+	case res.StatusCode == http.StatusMethodNotAllowed || res.StatusCode == http.StatusNotImplemented:
+		return rm, nil
+	case res.StatusCode != http.StatusOK:
+		return nil, StatusError{StatusCode: res.StatusCode, URL: uri}
+	}
+	rm.update(res)
+	rm.ranges = rm.size > 0 && hasToken(res.Header.Get("Accept-Ranges"), "bytes")
+	return rm, nil
+}
+
+func (rm *remote) update(res *http.Response) {
+	if rm.size < 0 {
+		rm.size = res.ContentLength
+	}
+	if rm.etag == "" {
+		rm.etag = res.Header.Get("ETag")
+	}
+	if rm.lastModified == "" {
+		rm.lastModified = res.Header.Get("Last-Modified")
+	}
+	if len(rm.digests) == 0 {
+		rm.digests = parseReprDigest(res.Header.Get("Repr-Digest"))
+	}
+}
+
+// validator returns the If-Range value, weak etags can't be used for range requests
+func (rm *remote) validator() string {
+	if rm.etag != "" && !strings.HasPrefix(rm.etag, "W/") {
+		return rm.etag
+	}
+	return rm.lastModified
+}
+
+func (d *Downloader) httpOptions() *httpx.HTTPOptions {
+	ho := httpx.NewHTTPOptions()
+	if d.opts.HTTPOptions != nil {
+		ho = d.opts.HTTPOptions()
+	}
+	// sizes and checksums are of the identity representation
+	return ho.Header("Accept-Encoding", "identity")
+}
+
+// single downloads whole file in one request, failed attempts restart from the beginning.
+func (d *Downloader) single(ctx context.Context, uri, part string, rm *remote) error {
+	return d.retry(ctx, func() error {
+		res, err := d.c.Get(ctx, uri, d.httpOptions())
+		if err != nil {
+			return err
+		}
+		defer res.Body.Close()
+		if res.StatusCode != http.StatusOK {
+			return StatusError{StatusCode: res.StatusCode, URL: uri}
+		}
+		rm.update(res)
+
+		f, err := os.Create(part)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		var written int64
+		_, err = io.CopyBuffer(f, progressReader{r: res.Body, fn: func(n int) {
+			written += int64(n)
+			d.progress(Progress{Downloaded: written, Total: rm.size})
+		}}, make([]byte, copyBufferSize))
+		if err != nil {
+			return err
+		}
+		return f.Close()
+	})
+}
+
+// parallel downloads the remaining chunks of file on worker pool.
+func (d *Downloader) parallel(ctx context.Context, uri, part, statePath string, rm *remote) error {
+	st, f, err := d.open(uri, part, statePath, rm)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var mu sync.Mutex
+	downloaded := st.downloaded()
+	written := func(c *chunk, n int) {
+		mu.Lock()
+		c.Done += int64(n)
+		downloaded += int64(n)
+		d.progress(Progress{Downloaded: downloaded, Total: rm.size})
+		mu.Unlock()
+	}
+	save := func() error {
+		mu.Lock()
+		defer mu.Unlock()
+		return st.save(statePath)
+	}
+
+	wp := workerpool.New[*chunk](d.opts.Concurrency)
+	go wp.Run()
+	go func() {
+		defer wp.Close()
+		for _, c := range st.Chunks {
+			if c.remaining() == 0 {
+				continue
+			}
+			wp.Submit(func() (*chunk, error) {
+				if ctx.Err() != nil {
+					return c, ctx.Err()
+				}
+				return c, d.retry(ctx, func() error {
+					return d.fetch(ctx, uri, f, c, rm, written)
+				})
+			})
+		}
+	}()
+
+	var firstErr error
+	for r := range wp.ResCh {
+		err := r.Err
+		if err == nil {
+			// completed chunks are persisted right away so they are not downloaded again
+			err = save()
+		}
+		if err != nil && firstErr == nil {
+			firstErr = err
+			cancel()
+		}
+	}
+	if err := save(); err != nil && firstErr == nil {
+		firstErr = err
+	}
+	if firstErr != nil {
+		return firstErr
+	}
+	return f.Close()
+}
+
+// open loads the state of previous download, if remote file changed since then
+// or state is missing the download starts over.
+func (d *Downloader) open(uri, part, statePath string, rm *remote) (*state, *os.File, error) {
+	st, err := loadState(statePath)
+	if err == nil && st.matches(uri, rm) {
+		if fi, serr := os.Stat(part); serr == nil && fi.Size() == rm.size {
+			f, err := os.OpenFile(part, os.O_RDWR, 0)
+			return st, f, err
+		}
+	}
+
+	st = newState(uri, rm, d.opts.ChunkSize)
+	f, err := os.Create(part)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := f.Truncate(rm.size); err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	if err := st.save(statePath); err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	return st, f, nil
+}
+
+// fetch downloads the remaining bytes of chunk with range request.
+func (d *Downloader) fetch(
+	ctx context.Context,
+	uri string,
+	f *os.File,
+	c *chunk,
+	rm *remote,
+	written func(*chunk, int),
+) error {
+	start := c.Start + c.Done
+	ho := d.httpOptions().Header("Range", fmt.Sprintf("bytes=%d-%d", start, c.End))
+	if v := rm.validator(); v != "" {
+		ho.Header("If-Range", v)
+	}
+	res, err := d.c.Get(ctx, uri, ho)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusPartialContent:
+	case http.StatusOK, http.StatusRequestedRangeNotSatisfiable:
+		// If-Range didn't match or file got shorter
+		return ErrResourceChanged
+	default:
+		return StatusError{StatusCode: res.StatusCode, URL: uri}
+	}
+	if first, size, ok := parseContentRange(res.Header.Get("Content-Range")); !ok ||
+		first != start || size >= 0 && size != rm.size {
+		return ErrResourceChanged
+	}
+
+	buf := make([]byte, copyBufferSize)
+	body := io.LimitReader(res.Body, c.End-start+1)
+	for off := start; off <= c.End; {
+		n, rerr := body.Read(buf)
+		if n > 0 {
+			if _, err := f.WriteAt(buf[:n], off); err != nil {
+				return err
+			}
+			off += int64(n)
+			written(c, n)
+		}
+		if rerr == io.EOF {
+			if off <= c.End {
+				return io.ErrUnexpectedEOF
+			}
+			break
+		}
+		if rerr != nil {
+			return rerr
+		}
+	}
+	return nil
+}
+
+// retry calls fn till it succeeds, context is done or error is permanent.
+func (d *Downloader) retry(ctx context.Context, fn func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		var se StatusError
+		if attempt > d.opts.MaxRetries || errors.Is(err, ErrResourceChanged) ||
+			errors.As(err, &se) && !se.temporary() {
+			return err
+		}
+
+		d.mu.Lock()
+		wait := d.opts.Backoff.NextWaitDuration(nil, attempt)
+		d.mu.Unlock()
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+func (d *Downloader) progress(p Progress) {
+	if d.opts.Progress != nil {
+		d.opts.Progress(p)
+	}
+}
+
+// progressReader reports every read to fn
+type progressReader struct {
+	r  io.Reader
+	fn func(int)
+}
+
+func (p progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if n > 0 {
+		p.fn(n)
+	}
+	return n, err
+}
+
+// parseContentRange parses "bytes first-last/size" header value
+func parseContentRange(v string) (first, size int64, ok bool) {
+	v, found := strings.CutPrefix(v, "bytes ")
+	if !found {
+		return 0, 0, false
+	}
+	rng, total, found := strings.Cut(v, "/")
+	if !found {
+		return 0, 0, false
+	}
+	fv, _, found := strings.Cut(rng, "-")
+	if !found {
+		return 0, 0, false
+	}
+	first, err := strconv.ParseInt(fv, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	size = -1
+	if total != "*" {
+		if size, err = strconv.ParseInt(total, 10, 64); err != nil {
+			return 0, 0, false
+		}
+	}
+	return first, size, true
+}
+
+func hasToken(v, token string) bool {
+	for t := range strings.SplitSeq(v, ",") {
+		if strings.EqualFold(strings.TrimSpace(t), token) {
+			return true
+		}
+	}
+	return false
+}
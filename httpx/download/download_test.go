@@ -0,0 +1,133 @@
+package download
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"collections/httpx"
+)
+
+func content(size int) []byte {
+	b := make([]byte, size)
+	r := rand.New(rand.NewPCG(1, 2))
+	for i := range b {
+		b[i] = byte(r.Uint32())
+	}
+	return b
+}
+
+func TestDownloadResume(t *testing.T) {
+	data := content(1 << 20)
+	sum := sha256.Sum256(data)
+	var served atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Repr-Digest", "sha-256=:"+base64.StdEncoding.EncodeToString(sum[:])+":")
+		cw := &countingWriter{ResponseWriter: w, n: &served}
+		http.ServeContent(cw, r, "file.bin", time.Time{}, bytes.NewReader(data))
+	}))
+	defer srv.Close()
+
+	dst := filepath.Join(t.TempDir(), "file.bin")
+	ctx, cancel := context.WithCancel(context.Background())
+	d := New(httpx.New(false), Options{
+		Concurrency: 2,
+		ChunkSize:   64 * 1024,
+		Progress: func(p Progress) {
+			if p.Downloaded >= int64(len(data)/2) {
+				cancel()
+			}
+		},
+	})
+	if err := d.Download(ctx, srv.URL, dst); !errors.Is(err, context.Canceled) {
+		t.Fatalf("wanted context.Canceled got %v", err)
+	}
+	if _, err := os.Stat(dst + ".part.state"); err != nil {
+		t.Fatalf("state file missing after interrupted download: %v", err)
+	}
+
+	first := served.Swap(0)
+	var last Progress
+	d = New(httpx.New(false), Options{
+		ChunkSize: 64 * 1024,
+		SHA256:    hex.EncodeToString(sum[:]),
+		Progress:  func(p Progress) { last = p },
+	})
+	if err := d.Download(context.Background(), srv.URL, dst); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatal("downloaded file doesn't match")
+	}
+	if first+served.Load() > int64(len(data))+4*64*1024 {
+		t.Errorf("resumed download fetched too much first=%d second=%d", first, served.Load())
+	}
+	if last.Downloaded != int64(len(data)) || last.Total != int64(len(data)) {
+		t.Errorf("unexpected final progress %+v", last)
+	}
+	if _, err := os.Stat(dst + ".part.state"); !os.IsNotExist(err) {
+		t.Error("state file is not removed")
+	}
+}
+
+func TestDownloadSingleStream(t *testing.T) {
+	data := content(100 * 1024)
+	var fails atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Range") != "" {
+			t.Error("range request sent to server without range support")
+		}
+		if r.Method == http.MethodGet && fails.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write(data)
+	}))
+	defer srv.Close()
+
+	dst := filepath.Join(t.TempDir(), "file.bin")
+	sum := sha256.Sum256(data)
+	d := New(httpx.New(false), Options{SHA256: "00"})
+	err := d.Download(context.Background(), srv.URL, dst)
+	var ce ChecksumError
+	if !errors.As(err, &ce) || ce.Want != "00" || ce.Got != hex.EncodeToString(sum[:]) {
+		t.Fatalf("wanted ChecksumError with computed checksum got %v", err)
+	}
+	if _, err := os.Stat(dst + ".part"); !os.IsNotExist(err) {
+		t.Error("corrupt partial file is not removed")
+	}
+
+	d = New(httpx.New(false), Options{SHA256: hex.EncodeToString(sum[:])})
+	if err := d.Download(context.Background(), srv.URL, dst); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile(dst); !bytes.Equal(got, data) {
+		t.Error("downloaded file doesn't match")
+	}
+}
+
+type countingWriter struct {
+	http.ResponseWriter
+	n *atomic.Int64
+}
+
+func (w *countingWriter) Write(b []byte) (int, error) {
+	w.n.Add(int64(len(b)))
+	return w.ResponseWriter.Write(b)
+}
@@ -0,0 +1,94 @@
+package download
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// chunk is the inclusive byte range of file, Done is the number of bytes already written.
+type chunk struct {
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+	Done  int64 `json:"done"`
+}
+
+func (c *chunk) remaining() int64 {
+	return c.End - c.Start + 1 - c.Done
+}
+
+// state is persisted next to the partial file so download can be resumed.
+type state struct {
+	URL          string   `json:"url"`
+	Size         int64    `json:"size"`
+	ETag         string   `json:"etag,omitempty"`
+	LastModified string   `json:"last_modified,omitempty"`
+	Chunks       []*chunk `json:"chunks"`
+}
+
+func newState(uri string, rm *remote, chunkSize int64) *state {
+	st := &state{URL: uri, Size: rm.size, ETag: rm.etag, LastModified: rm.lastModified}
+	for start := int64(0); start < rm.size; start += chunkSize {
+		st.Chunks = append(st.Chunks, &chunk{Start: start, End: min(start+chunkSize, rm.size) - 1})
+	}
+	return st
+}
+
+func loadState(path string) (*state, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	st := &state{}
+	if err := json.Unmarshal(b, st); err != nil {
+		return nil, err
+	}
+	return st, nil
+}
+
+// matches reports whether state belongs to the same version of remote file. Resume
+// is only safe when remote file has a validator.
+func (st *state) matches(uri string, rm *remote) bool {
+	if st.URL != uri || st.Size != rm.size || rm.validator() == "" {
+		return false
+	}
+	if st.ETag != rm.etag || st.LastModified != rm.lastModified {
+		return false
+	}
+	for _, c := range st.Chunks {
+		if c.Done < 0 || c.remaining() < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (st *state) downloaded() int64 {
+	var n int64
+	for _, c := range st.Chunks {
+		n += c.Done
+	}
+	return n
+}
+
+// save writes state to temporary file and renames it so crash never leaves partial state
+func (st *state) save(path string) error {
+	b, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,120 @@
+package download
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+)
+
+// ChecksumError is returned when downloaded file doesn't match the expected checksum.
+// Want is the checksum of options or the one advertised by server, Got is computed from
+// the downloaded file. Both are hex encoded.
+type ChecksumError struct {
+	Algorithm string
+	Want      string
+	Got       string
+}
+
+func (e ChecksumError) Error() string {
+	return fmt.Sprintf("download: checksum mismatch algorithm=%s, want=%s, got=%s", e.Algorithm, e.Want, e.Got)
+}
+
+// SizeError is returned when size of downloaded file doesn't match Content-Length.
+type SizeError struct {
+	Want int64
+	Got  int64
+}
+
+func (e SizeError) Error() string {
+	return fmt.Sprintf("download: size mismatch want=%d, got=%d", e.Want, e.Got)
+}
+
+// verify checks the size and checksums of downloaded file
+func (d *Downloader) verify(part string, rm *remote) error {
+	f, err := os.Open(part)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if rm.size >= 0 && fi.Size() != rm.size {
+		return SizeError{Want: rm.size, Got: fi.Size()}
+	}
+
+	// checksum of options is checked before the ones advertised by server
+	type check struct{ alg, want string }
+	var checks []check
+	if d.opts.SHA256 != "" {
+		checks = append(checks, check{"sha-256", strings.ToLower(d.opts.SHA256)})
+	}
+	for _, alg := range slices.Sorted(maps.Keys(rm.digests)) {
+		checks = append(checks, check{alg, rm.digests[alg]})
+	}
+	if len(checks) == 0 {
+		return nil
+	}
+
+	hashes := map[string]hash.Hash{}
+	var writers []io.Writer
+	for _, c := range checks {
+		if _, ok := hashes[c.alg]; !ok {
+			h := newHash(c.alg)
+			hashes[c.alg] = h
+			writers = append(writers, h)
+		}
+	}
+	if _, err := io.Copy(io.MultiWriter(writers...), f); err != nil {
+		return err
+	}
+	for _, c := range checks {
+		if got := hex.EncodeToString(hashes[c.alg].Sum(nil)); got != c.want {
+			return ChecksumError{Algorithm: c.alg, Want: c.want, Got: got}
+		}
+	}
+	return nil
+}
+
+func newHash(alg string) hash.Hash {
+	if alg == "sha-512" {
+		return sha512.New()
+	}
+	return sha256.New()
+}
+
+// parseReprDigest parses sha-256 and sha-512 digests of Repr-Digest header as specified by
+// RFC 9530, e.g. "sha-256=:base64:", other algorithms are ignored. Digests are hex encoded.
+func parseReprDigest(v string) map[string]string {
+	digests := map[string]string{}
+	for member := range strings.SplitSeq(v, ",") {
+		alg, value, ok := strings.Cut(strings.TrimSpace(member), "=")
+		if !ok {
+			continue
+		}
+		alg = strings.ToLower(alg)
+		if alg != "sha-256" && alg != "sha-512" {
+			continue
+		}
+		value, _, _ = strings.Cut(value, ";")
+		if len(value) < 2 || value[0] != ':' || value[len(value)-1] != ':' {
+			continue
+		}
+		sum, err := base64.StdEncoding.DecodeString(value[1 : len(value)-1])
+		if err != nil {
+			continue
+		}
+		digests[alg] = hex.EncodeToString(sum)
+	}
+	return digests
+}
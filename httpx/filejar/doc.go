@@ -0,0 +1,3 @@
+// Package filejar contains cookie jar which persists cookies to disk as JSON or Netscape
+// cookies.txt file so sessions survive the restarts
+package filejar
@@ -0,0 +1,143 @@
+package filejar
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const httpOnlyPrefix = "#HttpOnly_"
+
+// Import adds cookies read from r in format, expired cookies are skipped and cookies
+// with same domain, path and name are replaced.
+func (j *Jar) Import(r io.Reader, format Format) error {
+	var cookies []*Cookie
+	var err error
+	switch format {
+	case JSON:
+		err = json.NewDecoder(r).Decode(&cookies)
+		if err == io.EOF {
+			err = nil
+		}
+	case Netscape:
+		cookies, err = readNetscape(r)
+	default:
+		err = fmt.Errorf("filejar: unknown format %d", format)
+	}
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for _, c := range cookies {
+		if c.expired(now) || c.Name == "" && c.Value == "" {
+			continue
+		}
+		c.Domain = strings.ToLower(c.Domain)
+		if c.Path == "" {
+			c.Path = "/"
+		}
+		if c.Creation.IsZero() {
+			c.Creation = now
+		}
+		j.cookies[c.key()] = c
+	}
+	return nil
+}
+
+// Export writes the cookies to w in format, session cookies are skipped
+// if [Options.SkipSession] is set.
+func (j *Jar) Export(w io.Writer, format Format) error {
+	var cookies []Cookie
+	for _, c := range j.List("") {
+		if j.opts.SkipSession && c.Expires.IsZero() {
+			continue
+		}
+		cookies = append(cookies, c)
+	}
+
+	switch format {
+	case JSON:
+		if cookies == nil {
+			cookies = []Cookie{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(cookies)
+	case Netscape:
+		return writeNetscape(w, cookies)
+	}
+	return fmt.Errorf("filejar: unknown format %d", format)
+}
+
+// writeNetscape writes cookies.txt, session cookies have zero expiry.
+func writeNetscape(w io.Writer, cookies []Cookie) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("# Netscape HTTP Cookie File\n\n")
+	for _, c := range cookies {
+		domain := c.Domain
+		if !c.HostOnly {
+			domain = "." + domain
+		}
+		if c.HttpOnly {
+			domain = httpOnlyPrefix + domain
+		}
+		var expires int64
+		if !c.Expires.IsZero() {
+			expires = c.Expires.Unix()
+		}
+		fmt.Fprintf(bw, "%s\t%s\t%s\t%s\t%d\t%s\t%s\n",
+			domain, netscapeBool(!c.HostOnly), c.Path, netscapeBool(c.Secure), expires, c.Name, c.Value)
+	}
+	return bw.Flush()
+}
+
+func readNetscape(r io.Reader) ([]*Cookie, error) {
+	var cookies []*Cookie
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimRight(sc.Text(), "\r")
+		httpOnly := false
+		if rest, ok := strings.CutPrefix(line, httpOnlyPrefix); ok {
+			line, httpOnly = rest, true
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		f := strings.Split(line, "\t")
+		if len(f) != 7 {
+			return nil, fmt.Errorf("filejar: invalid cookies.txt line=%d", n)
+		}
+		expires, err := strconv.ParseInt(f[4], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("filejar: invalid expiry at line=%d: %w", n, err)
+		}
+		c := &Cookie{
+			Domain:   strings.TrimPrefix(f[0], "."),
+			HostOnly: !strings.EqualFold(f[1], "TRUE"),
+			Path:     f[2],
+			Secure:   strings.EqualFold(f[3], "TRUE"),
+			Name:     f[5],
+			Value:    f[6],
+			HttpOnly: httpOnly,
+		}
+		if expires > 0 {
+			c.Expires = time.Unix(expires, 0)
+		}
+		cookies = append(cookies, c)
+	}
+	return cookies, sc.Err()
+}
+
+func netscapeBool(b bool) string {
+	if b {
+		return "TRUE"
+	}
+	return "FALSE"
+}
@@ -0,0 +1,387 @@
+package filejar
+
+import (
+	"cmp"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PublicSuffixList provides the public suffix of a domain, it's compatible with
+// [net/http/cookiejar.PublicSuffixList] so golang.org/x/net/publicsuffix.List can be used.
+type PublicSuffixList interface {
+	PublicSuffix(domain string) string
+	String() string
+}
+
+// Format is the file format of jar.
+type Format int
+
+const (
+	// JSON stores cookies as JSON array of [Cookie]
+	JSON Format = iota
+	// Netscape stores cookies as cookies.txt used by curl, wget and browsers extensions
+	Netscape
+)
+
+// Cookie is the stored cookie.
+type Cookie struct {
+	Name     string        `json:"name"`
+	Value    string        `json:"value"`
+	Domain   string        `json:"domain"`
+	Path     string        `json:"path"`
+	Secure   bool          `json:"secure,omitempty"`
+	HttpOnly bool          `json:"http_only,omitempty"`
+	SameSite http.SameSite `json:"same_site,omitempty"`
+	// HostOnly cookies are sent only to Domain, not to its subdomains
+	HostOnly bool `json:"host_only,omitempty"`
+	// Expires is zero for session cookies
+	Expires  time.Time `json:"expires,omitzero"`
+	Creation time.Time `json:"creation"`
+}
+
+func (c *Cookie) key() string {
+	return c.Domain + ";" + c.Path + ";" + c.Name
+}
+
+func (c *Cookie) expired(now time.Time) bool {
+	return !c.Expires.IsZero() && !c.Expires.After(now)
+}
+
+// Options configures the [Jar].
+type Options struct {
+	// PublicSuffixList rejects cookies set for public suffixes such as "co.uk". If nil only
+	// cookies for top level domains are rejected, which is insecure for the shared suffixes.
+	PublicSuffixList PublicSuffixList
+	Format           Format
+	// AutoSave saves the jar whenever cookies change
+	AutoSave bool
+	// SkipSession doesn't persist the session cookies which have no expiry
+	SkipSession bool
+}
+
+// Jar is [net/http.CookieJar] which persists cookies to a file. It's safe for concurrent use.
+type Jar struct {
+	path string
+	opts Options
+
+	mu      sync.Mutex
+	cookies map[string]*Cookie
+	// saveMu orders the saves so older snapshot can't replace newer one
+	saveMu sync.Mutex
+}
+
+// New returns jar backed by file at path, existing cookies are loaded from it
+// and expired ones are pruned.
+func New(path string, opts *Options) (*Jar, error) {
+	j := &Jar{path: path, cookies: map[string]*Cookie{}}
+	if opts != nil {
+		j.opts = *opts
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return j, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if err := j.Import(f, j.opts.Format); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// SetCookies implements [net/http.CookieJar].
+func (j *Jar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return
+	}
+	host, err := canonicalHost(u.Host)
+	if err != nil {
+		return
+	}
+	now := time.Now()
+
+	j.mu.Lock()
+	changed := false
+	for _, hc := range cookies {
+		c, remove, ok := j.newCookie(host, u, hc, now)
+		if !ok {
+			continue
+		}
+		changed = true
+		if remove {
+			delete(j.cookies, c.key())
+			continue
+		}
+		if old, ok := j.cookies[c.key()]; ok {
+			c.Creation = old.Creation
+		}
+		j.cookies[c.key()] = c
+	}
+	j.mu.Unlock()
+
+	if changed && j.opts.AutoSave {
+		_ = j.Save()
+	}
+}
+
+// newCookie validates the cookie sent by host, remove is set for expired cookies.
+func (j *Jar) newCookie(host string, u *url.URL, hc *http.Cookie, now time.Time) (*Cookie, bool, bool) {
+	c := &Cookie{
+		Name:     hc.Name,
+		Value:    hc.Value,
+		Path:     hc.Path,
+		Secure:   hc.Secure,
+		HttpOnly: hc.HttpOnly,
+		SameSite: hc.SameSite,
+		Creation: now,
+	}
+	if c.Path == "" || c.Path[0] != '/' {
+		c.Path = defaultPath(u.Path)
+	}
+	domain, hostOnly, ok := j.domain(host, hc.Domain)
+	if !ok {
+		return nil, false, false
+	}
+	c.Domain, c.HostOnly = domain, hostOnly
+
+	switch {
+	case hc.MaxAge < 0:
+		return c, true, true
+	case hc.MaxAge > 0:
+		c.Expires = now.Add(time.Duration(hc.MaxAge) * time.Second)
+	case !hc.Expires.IsZero():
+		if !hc.Expires.After(now) {
+			return c, true, true
+		}
+		c.Expires = hc.Expires
+	}
+	return c, false, true
+}
+
+// domain returns the cookie domain for Domain attribute sent by host as specified in
+// RFC 6265 section 5.3 steps 4 to 6.
+func (j *Jar) domain(host, attr string) (string, bool, bool) {
+	if attr == "" {
+		return host, true, true
+	}
+	if net.ParseIP(host) != nil {
+		// IP addresses are only allowed as host only cookie
+		return host, true, host == attr
+	}
+	domain := strings.ToLower(strings.TrimSuffix(strings.TrimPrefix(attr, "."), "."))
+	if domain == "" || strings.HasPrefix(domain, ".") {
+		return "", false, false
+	}
+
+	if j.opts.PublicSuffixList != nil {
+		if ps := j.opts.PublicSuffixList.PublicSuffix(domain); ps != "" && !hasDotSuffix(domain, ps) {
+			if host == domain {
+				return host, true, true
+			}
+			return "", false, false
+		}
+	} else if !strings.Contains(domain, ".") {
+		// top level domains can't set cookies without public suffix list
+		if host == domain {
+			return host, true, true
+		}
+		return "", false, false
+	}
+
+	if host != domain && !hasDotSuffix(host, domain) {
+		return "", false, false
+	}
+	return domain, false, true
+}
+
+// Cookies implements [net/http.CookieJar], cookies with longer paths are listed first.
+func (j *Jar) Cookies(u *url.URL) []*http.Cookie {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil
+	}
+	host, err := canonicalHost(u.Host)
+	if err != nil {
+		return nil
+	}
+	path := u.Path
+	if path == "" {
+		path = "/"
+	}
+	now := time.Now()
+
+	j.mu.Lock()
+	var matched []*Cookie
+	for k, c := range j.cookies {
+		if c.expired(now) {
+			delete(j.cookies, k)
+			continue
+		}
+		if c.Secure && u.Scheme != "https" {
+			continue
+		}
+		if !c.domainMatch(host) || !pathMatch(path, c.Path) {
+			continue
+		}
+		matched = append(matched, c)
+	}
+	j.mu.Unlock()
+
+	slices.SortFunc(matched, func(a, b *Cookie) int {
+		if n := cmp.Compare(len(b.Path), len(a.Path)); n != 0 {
+			return n
+		}
+		return a.Creation.Compare(b.Creation)
+	})
+	out := make([]*http.Cookie, 0, len(matched))
+	for _, c := range matched {
+		out = append(out, &http.Cookie{Name: c.Name, Value: c.Value})
+	}
+	return out
+}
+
+func (c *Cookie) domainMatch(host string) bool {
+	if c.HostOnly {
+		return host == c.Domain
+	}
+	return host == c.Domain || hasDotSuffix(host, c.Domain)
+}
+
+// List returns cookies of domain and its subdomains, all cookies are listed if domain is empty.
+func (j *Jar) List(domain string) []Cookie {
+	domain = strings.ToLower(strings.TrimPrefix(domain, "."))
+	now := time.Now()
+	j.mu.Lock()
+	var out []Cookie
+	for _, c := range j.cookies {
+		if c.expired(now) {
+			continue
+		}
+		if domain == "" || c.Domain == domain || hasDotSuffix(c.Domain, domain) {
+			out = append(out, *c)
+		}
+	}
+	j.mu.Unlock()
+	slices.SortFunc(out, func(a, b Cookie) int {
+		return cmp.Or(
+			cmp.Compare(a.Domain, b.Domain),
+			cmp.Compare(a.Path, b.Path),
+			cmp.Compare(a.Name, b.Name),
+		)
+	})
+	return out
+}
+
+// Delete removes cookies of domain and its subdomains, if names are provided only the cookies
+// with those names are removed. It returns number of removed cookies.
+func (j *Jar) Delete(domain string, names ...string) int {
+	domain = strings.ToLower(strings.TrimPrefix(domain, "."))
+	j.mu.Lock()
+	n := 0
+	for k, c := range j.cookies {
+		if c.Domain != domain && !hasDotSuffix(c.Domain, domain) {
+			continue
+		}
+		if len(names) > 0 && !slices.Contains(names, c.Name) {
+			continue
+		}
+		delete(j.cookies, k)
+		n++
+	}
+	j.mu.Unlock()
+	if n > 0 && j.opts.AutoSave {
+		_ = j.Save()
+	}
+	return n
+}
+
+// Prune removes expired cookies and returns number of removed cookies.
+func (j *Jar) Prune() int {
+	now := time.Now()
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	n := 0
+	for k, c := range j.cookies {
+		if c.expired(now) {
+			delete(j.cookies, k)
+			n++
+		}
+	}
+	return n
+}
+
+// Save prunes the expired cookies and writes jar to its file atomically, file is only
+// readable by the owner as cookies are credentials.
+func (j *Jar) Save() error {
+	j.saveMu.Lock()
+	defer j.saveMu.Unlock()
+	j.Prune()
+	dir := filepath.Dir(j.path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, ".cookies-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if err := j.Export(f, j.opts.Format); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), j.path)
+}
+
+// canonicalHost strips port, brackets of IPv6 literal and trailing dot from host and lower
+// cases it
+func canonicalHost(host string) (string, error) {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	} else if strings.HasPrefix(host, "[") && strings.HasSuffix(host, "]") {
+		host = host[1 : len(host)-1]
+	}
+	host = strings.TrimSuffix(host, ".")
+	if host == "" {
+		return "", errors.New("filejar: empty host")
+	}
+	return strings.ToLower(host), nil
+}
+
+// defaultPath is the directory of request path as specified in RFC 6265 section 5.1.4
+func defaultPath(path string) string {
+	if path == "" || path[0] != '/' {
+		return "/"
+	}
+	i := strings.LastIndexByte(path, '/')
+	if i == 0 {
+		return "/"
+	}
+	return path[:i]
+}
+
+// pathMatch implements RFC 6265 section 5.1.4 path matching
+func pathMatch(reqPath, cookiePath string) bool {
+	if reqPath == cookiePath {
+		return true
+	}
+	if !strings.HasPrefix(reqPath, cookiePath) {
+		return false
+	}
+	return strings.HasSuffix(cookiePath, "/") || reqPath[len(cookiePath)] == '/'
+}
+
+func hasDotSuffix(s, suffix string) bool {
+	return len(s) > len(suffix) && s[len(s)-len(suffix)-1] == '.' && s[len(s)-len(suffix):] == suffix
+}
@@ -0,0 +1,120 @@
+package filejar
+
+import (
+	"bytes"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type suffixList struct{}
+
+func (suffixList) PublicSuffix(domain string) string {
+	if strings.HasSuffix(domain, ".co.uk") || domain == "co.uk" {
+		return "co.uk"
+	}
+	return domain[strings.LastIndexByte(domain, '.')+1:]
+}
+
+func (suffixList) String() string { return "test" }
+
+func names(cookies []*http.Cookie) string {
+	var out []string
+	for _, c := range cookies {
+		out = append(out, c.Name+"="+c.Value)
+	}
+	return strings.Join(out, " ")
+}
+
+func TestJar(t *testing.T) {
+	for _, format := range []Format{JSON, Netscape} {
+		path := filepath.Join(t.TempDir(), "cookies")
+		opts := &Options{PublicSuffixList: suffixList{}, Format: format, AutoSave: true}
+		j, err := New(path, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		u, _ := url.Parse("https://www.example.co.uk/app/login")
+		j.SetCookies(u, []*http.Cookie{
+			{Name: "session", Value: "s1"},
+			{Name: "shared", Value: "x", Domain: ".example.co.uk", Path: "/", MaxAge: 3600, HttpOnly: true},
+			{Name: "root", Value: "r", Path: "/", Secure: true},
+			{Name: "public", Value: "p", Domain: "co.uk"},
+			{Name: "old", Value: "o", Expires: time.Now().Add(-time.Hour)},
+		})
+
+		// reload from disk
+		j, err = New(path, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := names(j.Cookies(u)); got != "session=s1 shared=x root=r" && got != "session=s1 root=r shared=x" {
+			t.Errorf("format %d: unexpected cookies %q", format, got)
+		}
+		api, _ := url.Parse("http://api.example.co.uk/")
+		if got := names(j.Cookies(api)); got != "shared=x" {
+			t.Errorf("format %d: unexpected subdomain cookies %q", format, got)
+		}
+		if got := j.List("example.co.uk"); len(got) != 3 || !got[0].HttpOnly || got[0].HostOnly {
+			t.Errorf("format %d: unexpected list %+v", format, got)
+		}
+
+		if n := j.Delete("www.example.co.uk", "session"); n != 1 {
+			t.Errorf("format %d: wanted 1 deleted got %d", format, n)
+		}
+		var buf bytes.Buffer
+		if err := j.Export(&buf, Netscape); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(buf.String(), "#HttpOnly_.example.co.uk\tTRUE\t/\tFALSE\t") {
+			t.Errorf("format %d: unexpected cookies.txt\n%s", format, buf.String())
+		}
+	}
+}
+
+// TestAutoSaveConcurrent checks that concurrent saves don't lose cookies
+func TestAutoSaveConcurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cookies")
+	opts := &Options{AutoSave: true}
+	j, err := New(path, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ := url.Parse("https://example.com/")
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			j.SetCookies(u, []*http.Cookie{{Name: "c" + strconv.Itoa(i), Value: "v", MaxAge: 3600}})
+		}()
+	}
+	wg.Wait()
+
+	j, err = New(path, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(j.Cookies(u)); got != 20 {
+		t.Fatalf("wanted 20 saved cookies got %d", got)
+	}
+}
+
+func TestIPv6Host(t *testing.T) {
+	j, err := New(filepath.Join(t.TempDir(), "cookies"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ := url.Parse("http://[::1]/")
+	j.SetCookies(u, []*http.Cookie{{Name: "ip", Value: "v", Domain: "::1"}})
+	other, _ := url.Parse("http://[::1]:8080/")
+	if got := names(j.Cookies(other)); got != "ip=v" {
+		t.Fatalf("unexpected cookies of IPv6 host %q", got)
+	}
+}
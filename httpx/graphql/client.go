@@ -0,0 +1,195 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"sync/atomic"
+
+	"collections/httpx"
+)
+
+const maxErrorBody = 4096
+
+// Options configures the [Client].
+type Options struct {
+	// HTTPOptions returns options of every request, it can be used for setting auth
+	// headers or hooks. Content-Type and Accept headers are set by client. Retry hook is
+	// not used for requests with uploads as their streamed body can't be sent again.
+	HTTPOptions func() *httpx.HTTPOptions
+	// PersistedQueries enables automatic persisted queries, query is first sent as its
+	// SHA-256 hash and full query is only sent when server doesn't know the hash yet.
+	// It's disabled for the client once server reports persisted queries are not supported.
+	PersistedQueries bool
+}
+
+// Client executes GraphQL operations over http.
+type Client struct {
+	c        *httpx.Client
+	endpoint string
+	opts     Options
+	// noAPQ is set once server doesn't support persisted queries
+	noAPQ atomic.Bool
+}
+
+// New returns client sending operations to endpoint.
+func New(c *httpx.Client, endpoint string, opts Options) *Client {
+	return &Client{c: c, endpoint: endpoint, opts: opts}
+}
+
+// Request is GraphQL operation. Variables can contain [Upload] values which are sent
+// as multipart request.
+type Request struct {
+	Query         string         `json:"query,omitempty"`
+	OperationName string         `json:"operationName,omitempty"`
+	Variables     map[string]any `json:"variables,omitempty"`
+	Extensions    map[string]any `json:"extensions,omitempty"`
+}
+
+// response is the GraphQL response envelope
+type response[T any] struct {
+	Data       *T             `json:"data"`
+	Errors     Errors         `json:"errors"`
+	Extensions map[string]any `json:"extensions"`
+}
+
+// Do executes query with variables and decodes data of response into T. If response
+// contains errors [Errors] is returned along with partially decoded data.
+func Do[T any](ctx context.Context, c *Client, query string, vars map[string]any) (T, error) {
+	return Exec[T](ctx, c, Request{Query: query, Variables: vars})
+}
+
+// Exec executes the request and decodes data of response into T, see [Do].
+func Exec[T any](ctx context.Context, c *Client, req Request) (T, error) {
+	var zero T
+	vars, files := extractUploads(req.Variables)
+	if len(files) > 0 {
+		req.Variables = vars
+		res, err := c.send(ctx, false, func() (io.Reader, string, error) {
+			return multipartBody(req, files)
+		})
+		if err != nil {
+			return zero, err
+		}
+		return decode[T](res)
+	}
+
+	if c.opts.PersistedQueries && !c.noAPQ.Load() {
+		sum := sha256.Sum256([]byte(req.Query))
+		hashed := req
+		hashed.Query = ""
+		hashed.Extensions = withPersistedQuery(req.Extensions, hex.EncodeToString(sum[:]))
+		res, err := c.send(ctx, true, jsonBody(hashed))
+		if err != nil {
+			return zero, err
+		}
+		data, err := decode[T](res)
+		var es Errors
+		if !errors.As(err, &es) || !persistedQueryMiss(es, &c.noAPQ) {
+			return data, err
+		}
+		// register the query along with its hash
+		if !c.noAPQ.Load() {
+			req.Extensions = hashed.Extensions
+		}
+	}
+
+	res, err := c.send(ctx, true, jsonBody(req))
+	if err != nil {
+		return zero, err
+	}
+	return decode[T](res)
+}
+
+// send posts the body, body func returns reader and its content type. Retry hook of options
+// is dropped unless body is retryable as streamed body can't be sent again. Body is closed
+// when request fails.
+func (c *Client) send(ctx context.Context, retryable bool, body func() (io.Reader, string, error)) (*http.Response, error) {
+	r, contentType, err := body()
+	if err != nil {
+		return nil, err
+	}
+	var ho *httpx.HTTPOptions
+	if c.opts.HTTPOptions != nil {
+		ho = c.opts.HTTPOptions()
+	}
+	if ho == nil {
+		ho = httpx.NewHTTPOptions()
+	}
+	ho.Header("Content-Type", contentType).
+		Header("Accept", "application/graphql-response+json, application/json")
+	if !retryable {
+		ho.RetryHookV2(nil)
+	}
+	res, err := c.c.Post(ctx, c.endpoint, r, ho)
+	if rc, ok := r.(io.Closer); ok && err != nil {
+		// request can fail before body is read e.g. when client is shut down
+		rc.Close()
+	}
+	return res, err
+}
+
+func jsonBody(req Request) func() (io.Reader, string, error) {
+	return func() (io.Reader, string, error) {
+		b, err := json.Marshal(req)
+		if err != nil {
+			return nil, "", err
+		}
+		return bytes.NewReader(b), "application/json", nil
+	}
+}
+
+func decode[T any](res *http.Response) (T, error) {
+	var zero T
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return zero, err
+	}
+
+	var out response[T]
+	if err := json.Unmarshal(body, &out); err != nil || out.Data == nil && out.Errors == nil {
+		if res.StatusCode < 200 || res.StatusCode > 299 {
+			return zero, StatusError{StatusCode: res.StatusCode, Body: string(body[:min(len(body), maxErrorBody)])}
+		}
+		if err != nil {
+			return zero, err
+		}
+	}
+	if out.Data != nil {
+		zero = *out.Data
+	}
+	if len(out.Errors) > 0 {
+		return zero, out.Errors
+	}
+	return zero, nil
+}
+
+func withPersistedQuery(ext map[string]any, hash string) map[string]any {
+	out := make(map[string]any, len(ext)+1)
+	for k, v := range ext {
+		out[k] = v
+	}
+	out["persistedQuery"] = map[string]any{"version": 1, "sha256Hash": hash}
+	return out
+}
+
+// persistedQueryMiss reports whether full query has to be sent, it disables persisted
+// queries when server doesn't support them.
+func persistedQueryMiss(es Errors, disabled *atomic.Bool) bool {
+	for _, e := range es {
+		switch {
+		case e.Message == "PersistedQueryNotFound" || e.Code() == "PERSISTED_QUERY_NOT_FOUND":
+			return true
+		case e.Message == "PersistedQueryNotSupported" || e.Code() == "PERSISTED_QUERY_NOT_SUPPORTED":
+			disabled.Store(true)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,3 @@
+// Package graphql contains GraphQL client built on httpx client with typed results, automatic
+// persisted queries and multipart file uploads
+package graphql
@@ -0,0 +1,69 @@
+package graphql
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Location is the position in query the error refers to.
+type Location struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// Error is single entry of the errors array of response.
+type Error struct {
+	Message   string     `json:"message"`
+	Locations []Location `json:"locations,omitempty"`
+	// Path elements are field names as strings and list indexes as numbers
+	Path       []any          `json:"path,omitempty"`
+	Extensions map[string]any `json:"extensions,omitempty"`
+}
+
+func (e Error) Error() string {
+	if len(e.Path) == 0 {
+		return e.Message
+	}
+	path := make([]string, 0, len(e.Path))
+	for _, p := range e.Path {
+		path = append(path, fmt.Sprint(p))
+	}
+	return fmt.Sprintf("%s (path=%s)", e.Message, strings.Join(path, "."))
+}
+
+// Code returns the "code" extension of error, empty if not set.
+func (e Error) Code() string {
+	code, _ := e.Extensions["code"].(string)
+	return code
+}
+
+// Errors is returned when response contains errors. Data may still be partially decoded
+// as GraphQL returns data of fields which didn't fail.
+type Errors []Error
+
+func (es Errors) Error() string {
+	msgs := make([]string, 0, len(es))
+	for _, e := range es {
+		msgs = append(msgs, e.Error())
+	}
+	return "graphql: " + strings.Join(msgs, "; ")
+}
+
+// Unwrap allows matching single error with [errors.As].
+func (es Errors) Unwrap() []error {
+	out := make([]error, 0, len(es))
+	for _, e := range es {
+		out = append(out, e)
+	}
+	return out
+}
+
+// StatusError is returned when server responds with non 2xx status without errors array.
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e StatusError) Error() string {
+	return fmt.Sprintf("graphql: unexpected response status=%d, body=%s", e.StatusCode, e.Body)
+}
@@ -0,0 +1,182 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"collections/httpx"
+)
+
+type user struct {
+	User struct {
+		Name string `json:"name"`
+	} `json:"user"`
+}
+
+func TestDo(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+		if req.Variables["id"] == "missing" {
+			io.WriteString(w, `{"data":{"user":null},"errors":[{"message":"not found","path":["user"],"locations":[{"line":1,"column":2}],"extensions":{"code":"NOT_FOUND"}}]}`)
+			return
+		}
+		io.WriteString(w, `{"data":{"user":{"name":"bob"}}}`)
+	}))
+	defer srv.Close()
+	c := New(httpx.New(false), srv.URL, Options{})
+
+	got, err := Do[user](context.Background(), c, "query($id: ID!) { user(id: $id) { name } }", map[string]any{"id": "1"})
+	if err != nil || got.User.Name != "bob" {
+		t.Fatalf("got %+v, err=%v", got, err)
+	}
+
+	_, err = Do[user](context.Background(), c, "query($id: ID!) { user(id: $id) { name } }", map[string]any{"id": "missing"})
+	var e Error
+	if !errors.As(err, &e) || e.Code() != "NOT_FOUND" || e.Error() != "not found (path=user)" || e.Locations[0].Line != 1 {
+		t.Fatalf("unexpected error %#v", err)
+	}
+}
+
+func TestStatusError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad gateway", http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	_, err := Do[user](context.Background(), New(httpx.New(false), srv.URL, Options{}), "{ user { name } }", nil)
+	var se StatusError
+	if !errors.As(err, &se) || se.StatusCode != http.StatusBadGateway {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestPersistedQueries(t *testing.T) {
+	known := map[string]string{}
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		var req Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+		hash := req.Extensions["persistedQuery"].(map[string]any)["sha256Hash"].(string)
+		if req.Query != "" {
+			known[hash] = req.Query
+		}
+		if _, ok := known[hash]; !ok {
+			io.WriteString(w, `{"errors":[{"message":"PersistedQueryNotFound","extensions":{"code":"PERSISTED_QUERY_NOT_FOUND"}}]}`)
+			return
+		}
+		io.WriteString(w, `{"data":{"user":{"name":"bob"}}}`)
+	}))
+	defer srv.Close()
+	c := New(httpx.New(false), srv.URL, Options{PersistedQueries: true})
+
+	// first query isn't known and is registered, second is sent as hash only
+	for _, want := range []int32{2, 3} {
+		got, err := Do[user](context.Background(), c, "{ user { name } }", nil)
+		if err != nil || got.User.Name != "bob" {
+			t.Fatalf("got %+v, err=%v", got, err)
+		}
+		if n := requests.Load(); n != want {
+			t.Fatalf("want %d requests, got %d", want, n)
+		}
+	}
+}
+
+func TestUpload(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Error(err)
+			return
+		}
+		var req Request
+		if err := json.Unmarshal([]byte(r.FormValue("operations")), &req); err != nil {
+			t.Error(err)
+		}
+		var fileMap map[string][]string
+		if err := json.Unmarshal([]byte(r.FormValue("map")), &fileMap); err != nil {
+			t.Error(err)
+		}
+		files := req.Variables["files"].([]any)
+		if req.Variables["file"] != nil || len(files) != 1 || files[0] != nil {
+			t.Errorf("uploads must be null in variables %v", req.Variables)
+		}
+		var names []string
+		for _, field := range []string{"0", "1"} {
+			f, h, err := r.FormFile(field)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			b, _ := io.ReadAll(f)
+			names = append(names, fileMap[field][0]+"="+h.Filename+":"+string(b))
+		}
+		json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"files": names}})
+	}))
+	defer srv.Close()
+
+	type result struct {
+		Files []string `json:"files"`
+	}
+	// streamed uploads can't be resent so retry hook is skipped
+	opts := Options{PersistedQueries: true, HTTPOptions: func() *httpx.HTTPOptions {
+		return httpx.NewHTTPOptions().RetryHook(func(*http.Request, *http.Response, *http.Client, error) (*http.Response, error) {
+			t.Error("retry hook must not be used for uploads")
+			return nil, errors.New("unexpected retry")
+		})
+	}}
+	got, err := Do[result](context.Background(), New(httpx.New(false), srv.URL, opts),
+		"mutation($file: Upload!, $files: [Upload!]!) { upload(file: $file, files: $files) }",
+		map[string]any{
+			"file":  Upload{Filename: "a.txt", Reader: strings.NewReader("a")},
+			"files": []*Upload{{Filename: "b.txt", Reader: strings.NewReader("b")}},
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// map order of variables decides file numbering
+	want := map[string]bool{"variables.file=a.txt:a": true, "variables.files.0=b.txt:b": true}
+	if len(got.Files) != 2 || !want[got.Files[0]] || !want[got.Files[1]] {
+		t.Fatalf("unexpected files %v", got.Files)
+	}
+}
+
+// TestUploadFailedRequest checks that uploads of requests failing before being sent don't
+// leave goroutines behind
+func TestUploadFailedRequest(t *testing.T) {
+	hookErr := errors.New("hook failed")
+	closed := httpx.New(false)
+	closed.Shutdown(context.Background())
+	clients := []*Client{
+		New(closed, "http://example.com", Options{}),
+		New(httpx.New(false), "http://example.com", Options{HTTPOptions: func() *httpx.HTTPOptions {
+			return httpx.NewHTTPOptions().RequestHook(func(*http.Request) error { return hookErr })
+		}}),
+	}
+
+	before := runtime.NumGoroutine()
+	for range 20 {
+		for _, c := range clients {
+			_, err := Do[user](context.Background(), c, "mutation($file: Upload!) { upload(file: $file) }",
+				map[string]any{"file": Upload{Filename: "a.txt", Reader: strings.NewReader("a")}})
+			if !errors.Is(err, httpx.ErrClientClosed) && !errors.Is(err, hookErr) {
+				t.Fatalf("unexpected error %v", err)
+			}
+		}
+	}
+	if n := runtime.NumGoroutine(); n >= before+20 {
+		t.Fatalf("upload goroutines leaked, %d before %d after", before, n)
+	}
+}
@@ -0,0 +1,144 @@
+package graphql
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Upload is file variable sent as part of multipart request following
+// https://github.com/jaydenseric/graphql-multipart-request-spec. Reader is streamed
+// once so requests with uploads are not retried.
+type Upload struct {
+	Filename    string
+	ContentType string
+	Reader      io.Reader
+}
+
+// fileRef is the upload and object paths of variables referring to it
+type fileRef struct {
+	upload *Upload
+	path   string
+}
+
+// extractUploads returns copy of variables with uploads replaced by null along with the
+// uploads and their paths such as "variables.files.0".
+func extractUploads(vars map[string]any) (map[string]any, []fileRef) {
+	var files []fileRef
+	var walk func(v any, path string) any
+	walk = func(v any, path string) any {
+		switch val := v.(type) {
+		case Upload:
+			files = append(files, fileRef{upload: &val, path: path})
+			return nil
+		case *Upload:
+			files = append(files, fileRef{upload: val, path: path})
+			return nil
+		case map[string]any:
+			out := make(map[string]any, len(val))
+			for k, item := range val {
+				out[k] = walk(item, path+"."+k)
+			}
+			return out
+		case []any:
+			out := make([]any, len(val))
+			for i, item := range val {
+				out[i] = walk(item, path+"."+strconv.Itoa(i))
+			}
+			return out
+		case []Upload:
+			out := make([]any, len(val))
+			for i := range val {
+				out[i] = walk(val[i], path+"."+strconv.Itoa(i))
+			}
+			return out
+		case []*Upload:
+			out := make([]any, len(val))
+			for i, item := range val {
+				out[i] = walk(item, path+"."+strconv.Itoa(i))
+			}
+			return out
+		}
+		return v
+	}
+	out, _ := walk(vars, "variables").(map[string]any)
+	return out, files
+}
+
+// multipartBody streams the operations, map and files parts through pipe
+func multipartBody(req Request, files []fileRef) (io.Reader, string, error) {
+	operations, err := json.Marshal(req)
+	if err != nil {
+		return nil, "", err
+	}
+	fileMap := make(map[string][]string, len(files))
+	for i, f := range files {
+		fileMap[strconv.Itoa(i)] = []string{f.path}
+	}
+	mapping, err := json.Marshal(fileMap)
+	if err != nil {
+		return nil, "", err
+	}
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	write := func() {
+		err := mw.WriteField("operations", string(operations))
+		if err == nil {
+			err = mw.WriteField("map", string(mapping))
+		}
+		for i, f := range files {
+			if err != nil {
+				break
+			}
+			err = writeFile(mw, strconv.Itoa(i), f.upload)
+		}
+		if err == nil {
+			err = mw.Close()
+		}
+		pw.CloseWithError(err)
+	}
+	return &multipartReader{pr: pr, write: write}, mw.FormDataContentType(), nil
+}
+
+// multipartReader writes parts through pipe, writing starts with first Read so body of
+// request which fails before being sent doesn't leave goroutine behind.
+type multipartReader struct {
+	pr    *io.PipeReader
+	write func()
+	once  sync.Once
+}
+
+func (r *multipartReader) Read(p []byte) (int, error) {
+	r.once.Do(func() { go r.write() })
+	return r.pr.Read(p)
+}
+
+// Close stops writing of parts, started goroutine exits once its next write fails.
+func (r *multipartReader) Close() error {
+	r.once.Do(func() {})
+	return r.pr.Close()
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+func writeFile(mw *multipart.Writer, field string, u *Upload) error {
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`,
+		quoteEscaper.Replace(field), quoteEscaper.Replace(u.Filename)))
+	contentType := u.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	h.Set("Content-Type", contentType)
+	w, err := mw.CreatePart(h)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, u.Reader)
+	return err
+}
@@ -0,0 +1,3 @@
+// Package har contains recorder which captures http client traffic and writes it as HAR 1.2 file
+// which can be opened in browser devtools
+package har
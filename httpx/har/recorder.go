@@ -0,0 +1,434 @@
+package har
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"maps"
+	"mime"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"collections/httpx"
+)
+
+const (
+	redacted           = "[REDACTED]"
+	defaultMaxBodySize = 1024 * 1024
+)
+
+// Options configures the [Recorder].
+type Options struct {
+	// Base is the transport used for sending requests, [net/http.DefaultTransport] if nil
+	Base http.RoundTripper
+	// MaxBodySize is the maximum captured size of request and response body,
+	// bigger bodies are truncated. Default is 1MB, negative value disables body capture.
+	MaxBodySize int
+	// RedactHeaders are the headers whose values are replaced in archive.
+	// Authorization and Proxy-Authorization are always redacted.
+	RedactHeaders []string
+	// ExposeCookies disables redaction of cookie values and Cookie and Set-Cookie headers.
+	ExposeCookies bool
+}
+
+// Recorder is [net/http.RoundTripper] which captures every round trip as HAR entry.
+// Attempts of retry hook are recorded as separate entries with attempt number in comment.
+//
+// Entry is completed once the response body is read till EOF or closed and the request
+// body is consumed by transport.
+type Recorder struct {
+	mu      sync.Mutex
+	opts    Options
+	redact  map[string]struct{}
+	entries []*Entry
+}
+
+func NewRecorder(opts Options) *Recorder {
+	if opts.Base == nil {
+		opts.Base = http.DefaultTransport
+	}
+	if opts.MaxBodySize == 0 {
+		opts.MaxBodySize = defaultMaxBodySize
+	}
+	redact := map[string]struct{}{"Authorization": {}, "Proxy-Authorization": {}}
+	if !opts.ExposeCookies {
+		redact["Cookie"] = struct{}{}
+		redact["Set-Cookie"] = struct{}{}
+	}
+	for _, h := range opts.RedactHeaders {
+		redact[http.CanonicalHeaderKey(h)] = struct{}{}
+	}
+	return &Recorder{opts: opts, redact: redact}
+}
+
+// HAR returns the archive of recorded entries.
+func (r *Recorder) HAR() *HAR {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entries := make([]*Entry, 0, len(r.entries))
+	for _, e := range r.entries {
+		c := *e
+		entries = append(entries, &c)
+	}
+	return &HAR{Log: &Log{
+		Version: "1.2",
+		Creator: Creator{Name: "httpx", Version: "1.0"},
+		Entries: entries,
+	}}
+}
+
+// WriteTo writes recorded archive as JSON.
+func (r *Recorder) WriteTo(w io.Writer) (int64, error) {
+	data, err := json.MarshalIndent(r.HAR(), "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(data)
+	return int64(n), err
+}
+
+// WriteFile writes recorded archive into file at path.
+func (r *Recorder) WriteFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := r.WriteTo(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Reset removes all recorded entries.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	r.entries = nil
+	r.mu.Unlock()
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt := &roundTrip{rec: r, start: time.Now()}
+	rt.entry = &Entry{
+		StartedDateTime: rt.start.Format(time.RFC3339Nano),
+		Request:         r.request(req),
+		Timings:         Timings{Blocked: -1, DNS: -1, Connect: -1, SSL: -1},
+	}
+	if attempt := httpx.AttemptFromContext(req.Context()); attempt > 0 {
+		rt.entry.Comment = "attempt " + strconv.Itoa(attempt)
+	}
+
+	r2 := req.WithContext(httptrace.WithClientTrace(req.Context(), rt.clientTrace()))
+	if req.Body != nil && req.Body != http.NoBody && r.opts.MaxBodySize > 0 {
+		// body is written by transport concurrently with the response,
+		// so post data is only recorded once the body is consumed
+		contentType := req.Header.Get("Content-Type")
+		var body *captureBody
+		body = &captureBody{ReadCloser: req.Body, limit: r.opts.MaxBodySize, done: func() {
+			rt.set(func() {
+				rt.entry.Request.PostData = postData(contentType, body)
+				rt.entry.Request.BodySize = body.n
+			})
+		}}
+		r2.Body = body
+	}
+
+	r.mu.Lock()
+	r.entries = append(r.entries, rt.entry)
+	r.mu.Unlock()
+
+	res, err := r.opts.Base.RoundTrip(r2)
+	rt.gotResponse(res, err)
+	if err != nil {
+		return nil, err
+	}
+	if res.Body == nil {
+		rt.done()
+		return res, nil
+	}
+	limit := max(r.opts.MaxBodySize, 0)
+	res.Body = &captureBody{ReadCloser: res.Body, limit: limit, done: rt.done}
+	rt.resBody = res.Body.(*captureBody)
+	return res, nil
+}
+
+func (r *Recorder) request(req *http.Request) Request {
+	hr := Request{
+		Method:      req.Method,
+		URL:         req.URL.String(),
+		HTTPVersion: "HTTP/1.1",
+		Cookies:     r.cookies(req.Cookies()),
+		Headers:     r.headers(req.Header),
+		QueryString: []NameValue{},
+		HeadersSize: -1,
+		BodySize:    req.ContentLength,
+	}
+	if req.Host != "" && req.Host != req.URL.Host {
+		hr.Headers = append(hr.Headers, NameValue{Name: "Host", Value: req.Host})
+	}
+	for k, vs := range req.URL.Query() {
+		for _, v := range vs {
+			hr.QueryString = append(hr.QueryString, NameValue{Name: k, Value: v})
+		}
+	}
+	return hr
+}
+
+func (r *Recorder) headers(h http.Header) []NameValue {
+	out := make([]NameValue, 0, len(h))
+	for _, k := range slices.Sorted(maps.Keys(h)) {
+		_, redact := r.redact[k]
+		for _, v := range h[k] {
+			if redact {
+				v = redacted
+			}
+			out = append(out, NameValue{Name: k, Value: v})
+		}
+	}
+	return out
+}
+
+func (r *Recorder) cookies(cs []*http.Cookie) []Cookie {
+	out := make([]Cookie, 0, len(cs))
+	for _, c := range cs {
+		hc := Cookie{
+			Name:     c.Name,
+			Value:    c.Value,
+			Path:     c.Path,
+			Domain:   c.Domain,
+			HTTPOnly: c.HttpOnly,
+			Secure:   c.Secure,
+		}
+		if !c.Expires.IsZero() {
+			hc.Expires = c.Expires.Format(time.RFC3339)
+		}
+		if !r.opts.ExposeCookies {
+			hc.Value = redacted
+		}
+		out = append(out, hc)
+	}
+	return out
+}
+
+// roundTrip records timings and bodies of single round trip into entry.
+type roundTrip struct {
+	rec       *Recorder
+	entry     *Entry
+	resBody   *captureBody
+	start     time.Time
+	getConn   time.Time
+	gotConn   time.Time
+	dnsStart  time.Time
+	connStart time.Time
+	tlsStart  time.Time
+	wrote     time.Time
+	firstByte time.Time
+}
+
+func (rt *roundTrip) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		GetConn: func(string) {
+			rt.set(func() { rt.getConn = time.Now() })
+		},
+		DNSStart: func(httptrace.DNSStartInfo) {
+			rt.set(func() { rt.dnsStart = time.Now() })
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			rt.set(func() { rt.entry.Timings.DNS = ms(time.Since(rt.dnsStart)) })
+		},
+		ConnectStart: func(string, string) {
+			rt.set(func() {
+				if rt.connStart.IsZero() {
+					rt.connStart = time.Now()
+				}
+			})
+		},
+		TLSHandshakeStart: func() {
+			rt.set(func() { rt.tlsStart = time.Now() })
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			rt.set(func() { rt.entry.Timings.SSL = ms(time.Since(rt.tlsStart)) })
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			rt.set(func() {
+				rt.gotConn = time.Now()
+				if !rt.connStart.IsZero() {
+					// connect time includes ssl as per spec
+					rt.entry.Timings.Connect = ms(rt.gotConn.Sub(rt.connStart))
+				}
+				if !rt.getConn.IsZero() {
+					// blocked is the time spent waiting before dns lookup or connection
+					end := rt.gotConn
+					if !rt.dnsStart.IsZero() {
+						end = rt.dnsStart
+					} else if !rt.connStart.IsZero() {
+						end = rt.connStart
+					}
+					rt.entry.Timings.Blocked = ms(end.Sub(rt.getConn))
+				}
+				if info.Conn != nil {
+					host, port, err := net.SplitHostPort(info.Conn.RemoteAddr().String())
+					if err == nil {
+						rt.entry.ServerIPAddress = host
+						rt.entry.Connection = port
+					}
+				}
+			})
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			rt.set(func() {
+				rt.wrote = time.Now()
+				if !rt.gotConn.IsZero() {
+					rt.entry.Timings.Send = ms(rt.wrote.Sub(rt.gotConn))
+				}
+			})
+		},
+		GotFirstResponseByte: func() {
+			rt.set(func() {
+				rt.firstByte = time.Now()
+				if !rt.wrote.IsZero() {
+					rt.entry.Timings.Wait = ms(rt.firstByte.Sub(rt.wrote))
+				}
+			})
+		},
+	}
+}
+
+func (rt *roundTrip) gotResponse(res *http.Response, err error) {
+	r := rt.rec
+	rt.set(func() {
+		rt.entry.Time = ms(time.Since(rt.start))
+		if err != nil {
+			rt.entry.Response = Response{
+				Cookies:     []Cookie{},
+				Headers:     []NameValue{},
+				HeadersSize: -1,
+				BodySize:    -1,
+				Comment:     err.Error(),
+			}
+			return
+		}
+		rt.entry.Request.HTTPVersion = res.Proto
+		rt.entry.Response = Response{
+			Status:      res.StatusCode,
+			StatusText:  strings.TrimSpace(strings.TrimPrefix(res.Status, strconv.Itoa(res.StatusCode))),
+			HTTPVersion: res.Proto,
+			Cookies:     r.cookies(res.Cookies()),
+			Headers:     r.headers(res.Header),
+			Content:     Content{Size: -1, MimeType: res.Header.Get("Content-Type")},
+			RedirectURL: res.Header.Get("Location"),
+			HeadersSize: -1,
+			BodySize:    -1,
+		}
+	})
+}
+
+// done completes the entry once response body is consumed.
+func (rt *roundTrip) done() {
+	rt.set(func() {
+		now := time.Now()
+		rt.entry.Time = ms(now.Sub(rt.start))
+		if !rt.firstByte.IsZero() {
+			rt.entry.Timings.Receive = ms(now.Sub(rt.firstByte))
+		}
+		if rt.resBody == nil {
+			return
+		}
+		c := &rt.entry.Response.Content
+		c.Size = rt.resBody.n
+		rt.entry.Response.BodySize = rt.resBody.n
+		if rt.resBody.limit == 0 {
+			return
+		}
+		c.Text, c.Encoding = bodyText(rt.resBody.buf.Bytes())
+		if rt.resBody.truncated {
+			c.Comment = "body truncated to " + strconv.Itoa(rt.resBody.limit) + " bytes"
+		}
+	})
+}
+
+func (rt *roundTrip) set(f func()) {
+	rt.rec.mu.Lock()
+	f()
+	rt.rec.mu.Unlock()
+}
+
+func postData(contentType string, body *captureBody) *PostData {
+	pd := &PostData{MimeType: contentType, Params: []NameValue{}}
+	pd.Text, _ = bodyText(body.buf.Bytes())
+	if body.truncated {
+		pd.Comment = "body truncated to " + strconv.Itoa(body.limit) + " bytes"
+	}
+	if mt, _, _ := mime.ParseMediaType(contentType); mt == "application/x-www-form-urlencoded" {
+		if q, err := url.ParseQuery(pd.Text); err == nil {
+			for k, vs := range q {
+				for _, v := range vs {
+					pd.Params = append(pd.Params, NameValue{Name: k, Value: v})
+				}
+			}
+		}
+	}
+	return pd
+}
+
+// bodyText returns body as text, non UTF-8 bodies are base64 encoded.
+func bodyText(b []byte) (string, string) {
+	if utf8.Valid(b) {
+		return string(b), ""
+	}
+	return base64.StdEncoding.EncodeToString(b), "base64"
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// captureBody captures up to limit bytes read through it and calls done
+// once read till EOF, failed or closed.
+type captureBody struct {
+	io.ReadCloser
+	buf       bytes.Buffer
+	limit     int
+	n         int64
+	truncated bool
+	once      sync.Once
+	done      func()
+}
+
+func (c *captureBody) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.n += int64(n)
+	if room := c.limit - c.buf.Len(); room > 0 {
+		c.buf.Write(p[:min(n, room)])
+	}
+	if c.limit > 0 && c.n > int64(c.limit) {
+		c.truncated = true
+	}
+	if err != nil {
+		c.finish()
+	}
+	return n, err
+}
+
+func (c *captureBody) Close() error {
+	err := c.ReadCloser.Close()
+	c.finish()
+	return err
+}
+
+func (c *captureBody) finish() {
+	if c.done != nil {
+		c.once.Do(c.done)
+	}
+}
@@ -0,0 +1,117 @@
+package har
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"collections/httpx"
+)
+
+// TestRecorder records every attempt of retried request with redacted secrets and truncated bodies
+func TestRecorder(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		if calls.Add(1) == 1 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "secret"})
+		io.WriteString(w, "response body over the limit")
+	}))
+	defer srv.Close()
+
+	rec := NewRecorder(Options{MaxBodySize: 8, RedactHeaders: []string{"X-Api-Key"}})
+	ho := httpx.NewHTTPOptions().
+		Header("Authorization", "Bearer secret").
+		Header("X-Api-Key", "secret").
+		Header("Cookie", "session=secret").
+		Header("Content-Type", "text/plain").
+		RetryHook(func(req *http.Request, res *http.Response, hc *http.Client, err error) (*http.Response, error) {
+			if err != nil || res.StatusCode != http.StatusServiceUnavailable {
+				return res, err
+			}
+			io.Copy(io.Discard, res.Body)
+			res.Body.Close()
+			if req.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+			return hc.Do(req)
+		})
+	res, err := httpx.New(false).SetTransport(rec).Post(context.Background(), srv.URL, strings.NewReader("request body over the limit"), ho)
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.Copy(io.Discard, res.Body)
+	res.Body.Close()
+
+	var buf bytes.Buffer
+	if _, err := rec.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "secret") {
+		t.Errorf("archive must not contain secrets:\n%s", buf.String())
+	}
+	var archive struct {
+		Log struct {
+			Version string            `json:"version"`
+			Creator map[string]string `json:"creator"`
+			Entries []map[string]json.RawMessage
+		} `json:"log"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &archive); err != nil {
+		t.Fatal(err)
+	}
+	if archive.Log.Version != "1.2" || archive.Log.Creator["name"] == "" || len(archive.Log.Entries) != 2 {
+		t.Fatalf("unexpected log version=%s, creator=%v, entries=%d", archive.Log.Version, archive.Log.Creator, len(archive.Log.Entries))
+	}
+	for _, e := range archive.Log.Entries {
+		for _, field := range []string{"startedDateTime", "time", "request", "response", "cache", "timings"} {
+			if _, ok := e[field]; !ok {
+				t.Errorf("entry is missing required field %s", field)
+			}
+		}
+	}
+
+	h := rec.HAR()
+	for i, e := range h.Log.Entries {
+		if want := "attempt " + string(rune('1'+i)); e.Comment != want {
+			t.Errorf("want comment %q, got %q", want, e.Comment)
+		}
+		pd := e.Request.PostData
+		if pd == nil || pd.Text != "request " || pd.Comment == "" || e.Request.BodySize != 27 {
+			t.Errorf("unexpected post data %+v of size %d", pd, e.Request.BodySize)
+		}
+		for _, hdr := range e.Request.Headers {
+			switch hdr.Name {
+			case "Authorization", "X-Api-Key", "Cookie":
+				if hdr.Value != redacted {
+					t.Errorf("request header %s is not redacted", hdr.Name)
+				}
+			}
+		}
+		for _, c := range e.Request.Cookies {
+			if c.Value != redacted {
+				t.Errorf("request cookie %s is not redacted", c.Name)
+			}
+		}
+	}
+
+	last := h.Log.Entries[1].Response
+	if h.Log.Entries[0].Response.Status != http.StatusServiceUnavailable || last.Status != http.StatusOK {
+		t.Errorf("unexpected statuses %d and %d", h.Log.Entries[0].Response.Status, last.Status)
+	}
+	if last.Content.Text != "response" || last.Content.Size != 28 || last.Content.Comment == "" {
+		t.Errorf("unexpected truncated content %+v", last.Content)
+	}
+	if len(last.Cookies) != 1 || last.Cookies[0].Value != redacted {
+		t.Errorf("response cookies are not redacted %+v", last.Cookies)
+	}
+}
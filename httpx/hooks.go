@@ -0,0 +1,112 @@
+package httpx
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// RequestInfo describes the request executed by [Client], it's passed to v2 hooks.
+type RequestInfo struct {
+	// Attempt is the attempt number, it's 1 in request hook and number of the last
+	// attempt in response hook. It's not updated while the hook resends the request, use
+	// [RequestInfoFromContext] for the current attempt.
+	Attempt int
+	// Start is the time Exec was called
+	Start time.Time
+	// ClientName is the name set with [Client.SetName]
+	ClientName string
+}
+
+// v2 hooks receive the request context and [RequestInfo] so they can use request scoped
+// values such as loggers and deadlines. Existing hooks are adapted with V2 methods.
+type (
+	RequestHookV2  func(context.Context, RequestInfo, *http.Request) error
+	ResponseHookV2 func(context.Context, RequestInfo, *http.Request, *http.Response) error
+	RetryHookV2    func(context.Context, RequestInfo, *http.Request, *http.Response, *http.Client, error) (*http.Response, error)
+)
+
+// V2 adapts the hook to [RequestHookV2], nil hook returns nil.
+func (h RequestHook) V2() RequestHookV2 {
+	if h == nil {
+		return nil
+	}
+	return func(_ context.Context, _ RequestInfo, req *http.Request) error {
+		return h(req)
+	}
+}
+
+// V2 adapts the hook to [ResponseHookV2], nil hook returns nil.
+func (h ResponseHook) V2() ResponseHookV2 {
+	if h == nil {
+		return nil
+	}
+	return func(_ context.Context, _ RequestInfo, req *http.Request, res *http.Response) error {
+		return h(req, res)
+	}
+}
+
+// V2 adapts the hook to [RetryHookV2], nil hook returns nil.
+func (h RetryHook) V2() RetryHookV2 {
+	if h == nil {
+		return nil
+	}
+	return func(
+		_ context.Context,
+		_ RequestInfo,
+		req *http.Request,
+		res *http.Response,
+		hc *http.Client,
+		err error,
+	) (*http.Response, error) {
+		return h(req, res, hc, err)
+	}
+}
+
+// RequestHookV2 sets the request hook, it replaces hook set with [HTTPOptions.RequestHook].
+func (ho *HTTPOptions) RequestHookV2(hook RequestHookV2) *HTTPOptions {
+	ho.requestHook = hook
+	return ho
+}
+
+// ResponseHookV2 sets the response hook, it replaces hook set with [HTTPOptions.ResponseHook].
+func (ho *HTTPOptions) ResponseHookV2(hook ResponseHookV2) *HTTPOptions {
+	ho.responseHook = hook
+	return ho
+}
+
+// RetryHookV2 sets the retry hook, it replaces hook set with [HTTPOptions.RetryHook].
+func (ho *HTTPOptions) RetryHookV2(hook RetryHookV2) *HTTPOptions {
+	ho.retryHook = hook
+	return ho
+}
+
+// SetName sets the client name passed to hooks in [RequestInfo], it tells apart clients
+// sharing the hooks.
+func (c *Client) SetName(name string) *Client {
+	c.name = name
+	return c
+}
+
+// RequestInfoFromContext returns info of request being executed by [Client] with ctx, Attempt
+// is the current attempt so retry hooks can read it after resending the request. False is
+// returned for requests not sent through [Client].
+func RequestInfoFromContext(ctx context.Context) (RequestInfo, bool) {
+	st := execStateFrom(ctx)
+	if st == nil {
+		return RequestInfo{}, false
+	}
+	return RequestInfo{
+		Attempt:    max(int(st.attempt.Load()), 1),
+		Start:      st.start,
+		ClientName: st.name,
+	}, true
+}
+
+// requestInfo returns info of request being executed with ctx
+func (c *Client) requestInfo(ctx context.Context) RequestInfo {
+	if info, ok := RequestInfoFromContext(ctx); ok {
+		return info
+	}
+	return RequestInfo{Attempt: 1, Start: time.Now(), ClientName: c.name}
+}
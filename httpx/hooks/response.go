@@ -11,6 +11,8 @@ import (
 	"io"
 	"mime"
 	"net/http"
+
+	"collections/httpx/internal/bodylimit"
 )
 
 // ResponseHook provides a response body hook with optional automatic
@@ -39,6 +41,10 @@ type ResponseHook[T any] struct {
 	// This guarantees callers can safely close both RawBody and [net/http.Response.Body] without
 	// error.
 	Decompressor func(io.Reader) (io.ReadCloser, error)
+	// MaxDecompressedSize limits the size of decompressed body to protect against
+	// decompression bombs, reading past it fails with error matching httpx.ErrBodyTooLarge.
+	// Zero is unlimited. It applies to bodies decompressed by hook as well as by transport.
+	MaxDecompressedSize int64
 	// RawBody is the (optionally decompressed) response body stream.
 	// If [AutoParse] is true, RawBody will already have been consumed.
 	RawBody io.ReadCloser
@@ -90,9 +96,12 @@ func (r *ResponseHook[T]) Hook(_ *http.Request, res *http.Response) error {
 		}
 	}
 
-	if res.Uncompressed && !r.Decompress {
+	if !r.Decompress || res.Uncompressed {
 		r.RawBody = res.Body
 	}
+	if r.MaxDecompressedSize > 0 && (r.RawBody != res.Body || res.Uncompressed) {
+		r.RawBody = bodylimit.Reader(r.RawBody, r.MaxDecompressedSize, -1)
+	}
 
 	if r.AutoParse {
 		mimeType, _, err := mime.ParseMediaType(res.Header.Get("Content-Type"))
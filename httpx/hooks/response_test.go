@@ -0,0 +1,32 @@
+package hooks
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	"collections/httpx/internal/bodylimit"
+)
+
+func TestMaxDecompressedSize(t *testing.T) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write(make([]byte, 1<<20))
+	zw.Close()
+
+	res := &http.Response{
+		Header: http.Header{"Content-Encoding": {"gzip"}, "Content-Type": {"application/json"}},
+		Body:   io.NopCloser(bytes.NewReader(buf.Bytes())),
+	}
+	hk := &ResponseHook[[]int]{Decompress: true, MaxDecompressedSize: 1024}
+	if err := hk.Hook(nil, res); err != nil {
+		t.Fatal(err)
+	}
+	n, err := io.Copy(io.Discard, hk.RawBody)
+	if !errors.Is(err, bodylimit.ErrTooLarge) || n != 1024 {
+		t.Errorf("wanted ErrBodyTooLarge after 1024 bytes got %v after %d", err, n)
+	}
+}
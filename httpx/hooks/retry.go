@@ -1,6 +1,7 @@
 package hooks
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"math"
@@ -103,7 +104,14 @@ func (hk *RetryHook) Hook(
 		}
 
 		totalWait += hk.Wait
-		time.Sleep(hk.Wait)
+		// cancelled request e.g. by client shutdown stops retrying
+		timer := time.NewTimer(hk.Wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, context.Cause(req.Context())
+		case <-timer.C:
+		}
 	}
 	return nil, RetryPollError{
 		Attempts:       hk.PollLimit,
@@ -0,0 +1,198 @@
+package hooks
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"sync"
+)
+
+// ErrRecordTooLarge is reported when single record exceeds the maximum record size.
+var ErrRecordTooLarge = errors.New("record exceeds maximum size")
+
+// RecordError is yielded for the record which failed to decode. Record is the 1 based
+// line number for NDJSON and 1 based element index for JSON arrays.
+type RecordError struct {
+	Record int
+	Err    error
+}
+
+func (e RecordError) Error() string {
+	return fmt.Sprintf("failed to decode record=%d: %s", e.Record, e.Err)
+}
+
+func (e RecordError) Unwrap() error {
+	return e.Err
+}
+
+// NDJSON returns iterator which decodes newline delimited JSON (JSON Lines) records from body
+// one at a time. Empty lines are skipped.
+//
+// Records which fail to decode or exceed maxRecordSize are yielded as [RecordError] and
+// iteration continues with next line. If maxRecordSize is zero or negative records are not
+// limited. Body is closed once iteration ends, including when the loop is stopped early,
+// and iteration ends with context error when ctx is done.
+func NDJSON[T any](ctx context.Context, body io.ReadCloser, maxRecordSize int) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		// body is closed once, either by cancelled ctx or when iteration ends
+		closeBody := sync.OnceFunc(func() { body.Close() })
+		defer closeBody()
+		stop := context.AfterFunc(ctx, closeBody)
+		defer stop()
+
+		br := bufio.NewReader(body)
+		for line := 1; ; line++ {
+			var zero T
+			rec, err := readLine(br, maxRecordSize)
+			if ctx.Err() != nil {
+				yield(zero, ctx.Err())
+				return
+			}
+			if errors.Is(err, ErrRecordTooLarge) {
+				if !yield(zero, RecordError{Record: line, Err: err}) {
+					return
+				}
+				continue
+			}
+			if err != nil && !errors.Is(err, io.EOF) {
+				yield(zero, err)
+				return
+			}
+
+			if rec = bytes.TrimSpace(rec); len(rec) > 0 {
+				var v T
+				if derr := json.Unmarshal(rec, &v); derr != nil {
+					if !yield(zero, RecordError{Record: line, Err: derr}) {
+						return
+					}
+				} else if !yield(v, nil) {
+					return
+				}
+			}
+			if errors.Is(err, io.EOF) {
+				return
+			}
+		}
+	}
+}
+
+// readLine reads single line without the delimiter. If line is longer than max
+// the rest of line is discarded and [ErrRecordTooLarge] is returned.
+func readLine(br *bufio.Reader, max int) ([]byte, error) {
+	var line []byte
+	for {
+		chunk, err := br.ReadSlice('\n')
+		if max <= 0 || len(line)+len(chunk) <= max+1 {
+			line = append(line, chunk...)
+		} else {
+			line = nil
+			max = -1
+		}
+		if errors.Is(err, bufio.ErrBufferFull) {
+			continue
+		}
+		if max < 0 && (err == nil || errors.Is(err, io.EOF)) {
+			// next read reports EOF for the record which was too large at the end of stream
+			return nil, ErrRecordTooLarge
+		}
+		return bytes.TrimSuffix(line, []byte{'\n'}), err
+	}
+}
+
+// JSONArray returns iterator which decodes elements of top level JSON array from body one
+// at a time.
+//
+// Elements which fail to decode into T are yielded as [RecordError] and iteration continues.
+// Malformed JSON and elements exceeding maxRecordSize end the iteration as stream can not be
+// resynchronised. maxRecordSize includes the whitespace and comma before the element, if it's
+// zero or negative elements are not limited. Body is closed once iteration ends, including
+// when the loop is stopped early, and iteration ends with context error when ctx is done.
+func JSONArray[T any](ctx context.Context, body io.ReadCloser, maxRecordSize int) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		// body is closed once, either by cancelled ctx or when iteration ends
+		closeBody := sync.OnceFunc(func() { body.Close() })
+		defer closeBody()
+		stop := context.AfterFunc(ctx, closeBody)
+		defer stop()
+
+		var zero T
+		wr := &windowReader{r: body}
+		dec := json.NewDecoder(wr)
+		fail := func(err error) {
+			if ctx.Err() != nil {
+				err = ctx.Err()
+			}
+			yield(zero, err)
+		}
+
+		tok, err := dec.Token()
+		if err != nil {
+			fail(err)
+			return
+		}
+		if d, ok := tok.(json.Delim); !ok || d != '[' {
+			fail(fmt.Errorf("expected start of JSON array got %v", tok))
+			return
+		}
+
+		for i := 1; dec.More(); i++ {
+			if maxRecordSize > 0 {
+				wr.limit = dec.InputOffset() + int64(maxRecordSize)
+			}
+			var v T
+			err := dec.Decode(&v)
+			if ctx.Err() != nil {
+				fail(ctx.Err())
+				return
+			}
+			var typeErr *json.UnmarshalTypeError
+			switch {
+			case errors.As(err, &typeErr):
+				// value is consumed so iteration can continue
+				if !yield(zero, RecordError{Record: i, Err: err}) {
+					return
+				}
+			case err != nil:
+				fail(RecordError{Record: i, Err: err})
+				return
+			default:
+				if !yield(v, nil) {
+					return
+				}
+			}
+		}
+
+		wr.limit = 0
+		if _, err := dec.Token(); err != nil {
+			fail(err)
+		}
+	}
+}
+
+// windowReader fails with [ErrRecordTooLarge] once read offset reaches limit,
+// zero limit disables the check.
+type windowReader struct {
+	r     io.Reader
+	read  int64
+	limit int64
+}
+
+func (w *windowReader) Read(p []byte) (int, error) {
+	if w.limit > 0 {
+		room := w.limit - w.read
+		if room <= 0 {
+			return 0, ErrRecordTooLarge
+		}
+		if int64(len(p)) > room {
+			p = p[:room]
+		}
+	}
+	n, err := w.r.Read(p)
+	w.read += int64(n)
+	return n, err
+}
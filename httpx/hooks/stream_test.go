@@ -0,0 +1,91 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+type item struct {
+	ID int `json:"id"`
+}
+
+type trackedBody struct {
+	io.Reader
+	closed bool
+}
+
+func (b *trackedBody) Close() error {
+	b.closed = true
+	return nil
+}
+
+func TestNDJSON(t *testing.T) {
+	body := &trackedBody{Reader: strings.NewReader(
+		"{\"id\":1}\r\n\n{\"id\":\"x\"}\n{\"id\":3,\"pad\":\"" + strings.Repeat("a", 64) + "\"}\n{\"id\":4}",
+	)}
+	var ids []int
+	var lines []int
+	for v, err := range NDJSON[item](context.Background(), body, 32) {
+		var re RecordError
+		if errors.As(err, &re) {
+			lines = append(lines, re.Record)
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, v.ID)
+	}
+	if len(ids) != 2 || ids[0] != 1 || ids[1] != 4 {
+		t.Errorf("wanted ids [1 4] got %v", ids)
+	}
+	if len(lines) != 2 || lines[0] != 3 || lines[1] != 4 {
+		t.Errorf("wanted failed lines [3 4] got %v", lines)
+	}
+	if !body.closed {
+		t.Error("body is not closed")
+	}
+}
+
+func TestJSONArray(t *testing.T) {
+	body := &trackedBody{Reader: strings.NewReader(`[{"id":1}, {"id":"x"}, {"id":3}, {"id":4}]`)}
+	var ids []int
+	for v, err := range JSONArray[item](context.Background(), body, 16) {
+		if err != nil {
+			if errors.As(err, new(RecordError)) {
+				continue
+			}
+			t.Fatal(err)
+		}
+		ids = append(ids, v.ID)
+		if v.ID == 3 {
+			break
+		}
+	}
+	if len(ids) != 2 || ids[0] != 1 || ids[1] != 3 {
+		t.Errorf("wanted ids [1 3] got %v", ids)
+	}
+	if !body.closed {
+		t.Error("body is not closed after break")
+	}
+
+	body = &trackedBody{Reader: strings.NewReader(`[{"id":1},{"id":2,"pad":"` + strings.Repeat("a", 64) + `"}]`)}
+	var last error
+	for _, err := range JSONArray[item](context.Background(), body, 32) {
+		last = err
+	}
+	if !errors.Is(last, ErrRecordTooLarge) {
+		t.Errorf("wanted ErrRecordTooLarge got %v", last)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, err := range JSONArray[item](ctx, &trackedBody{Reader: strings.NewReader(`[{"id":1}]`)}, 0) {
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("wanted context.Canceled got %v", err)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+type ctxKey struct{}
+
+func TestHooksV2(t *testing.T) {
+	attempts := 0
+	c := New(false).SetName("billing").SetTransport(roundTripFunc(func(*http.Request) (*http.Response, error) {
+		attempts++
+		if attempts == 1 {
+			return nil, errors.New("connection reset")
+		}
+		return okResponse(), nil
+	}))
+	ctx := context.WithValue(context.Background(), ctxKey{}, "request-id")
+	start := time.Now()
+
+	var infos []RequestInfo
+	check := func(hookCtx context.Context, info RequestInfo) {
+		if hookCtx.Value(ctxKey{}) != "request-id" {
+			t.Error("hook context doesn't carry request values")
+		}
+		if info.Start.Before(start) || info.ClientName != "billing" {
+			t.Errorf("unexpected info %+v", info)
+		}
+		infos = append(infos, info)
+	}
+	ho := NewHTTPOptions().
+		RequestHook(func(*http.Request) error {
+			t.Error("plain hook must be replaced by v2 hook")
+			return nil
+		}).
+		RequestHookV2(func(ctx context.Context, info RequestInfo, _ *http.Request) error {
+			check(ctx, info)
+			return nil
+		}).
+		RetryHookV2(func(ctx context.Context, info RequestInfo, req *http.Request, res *http.Response, hc *http.Client, err error) (*http.Response, error) {
+			check(ctx, info)
+			if err != nil {
+				res, err = hc.Do(req)
+			}
+			info, ok := RequestInfoFromContext(ctx)
+			if !ok {
+				t.Error("retry hook context doesn't carry request info")
+			}
+			check(ctx, info)
+			return res, err
+		})
+
+	res, err := c.Get(ctx, "http://example.com", ho)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if len(infos) != 3 || infos[0].Attempt != 1 || infos[1].Attempt != 1 || infos[2].Attempt != 2 {
+		t.Fatalf("unexpected attempts %+v", infos)
+	}
+}
+
+func TestHookAdapters(t *testing.T) {
+	c := New(false).SetTransport(roundTripFunc(func(*http.Request) (*http.Response, error) {
+		return okResponse(), nil
+	}))
+	var called []string
+	ho := NewHTTPOptions().
+		RequestHook(func(*http.Request) error {
+			called = append(called, "request")
+			return nil
+		}).
+		ResponseHook(func(*http.Request, *http.Response) error {
+			called = append(called, "response")
+			return nil
+		})
+	res, err := c.Get(context.Background(), "http://example.com", ho)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if len(called) != 2 || called[0] != "request" || called[1] != "response" {
+		t.Fatalf("unexpected hook calls %v", called)
+	}
+	if RequestHook(nil).V2() != nil || ResponseHook(nil).V2() != nil || RetryHook(nil).V2() != nil {
+		t.Fatal("nil hook must adapt to nil")
+	}
+}
@@ -0,0 +1,108 @@
+package httpx
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// traceRecords decodes the JSON records written by slog handler
+func traceRecords(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var recs []map[string]any
+	dec := json.NewDecoder(buf)
+	for dec.More() {
+		var rec map[string]any
+		if err := dec.Decode(&rec); err != nil {
+			t.Fatal(err)
+		}
+		recs = append(recs, rec)
+	}
+	return recs
+}
+
+func TestTraceRecords(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	var buf bytes.Buffer
+	c := New(true).SetTraceOptions(TraceOptions{
+		Logger:        slog.New(slog.NewJSONHandler(&buf, nil)),
+		Headers:       true,
+		RedactHeaders: []string{"X-Api-Key"},
+	})
+	ho := NewHTTPOptions().
+		Header("Authorization", "Bearer secret").
+		Header("X-Api-Key", "secret").
+		Header(RequestIDHeader, "req-1")
+	res, err := c.Get(context.Background(), srv.URL, ho)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	phases := map[string]bool{}
+	for _, rec := range traceRecords(t, &buf) {
+		if rec["request_id"] != "req-1" || rec["level"] != "INFO" {
+			t.Errorf("unexpected record %v", rec)
+		}
+		if (rec["key"] == "Authorization" || rec["key"] == "X-Api-Key") && rec["value"] != redacted {
+			t.Errorf("header %v is not redacted", rec["key"])
+		}
+		phases[rec["phase"].(string)] = true
+	}
+	for _, p := range []string{"get_conn", "connect", "got_conn", "wrote_headers", "wrote_request", "first_response_byte"} {
+		if !phases[p] {
+			t.Errorf("missing trace record for phase %s", p)
+		}
+	}
+}
+
+func TestTraceErrorLevel(t *testing.T) {
+	// closed listener makes connect fail
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	info := slog.LevelInfo
+	tests := []struct {
+		name  string
+		level *slog.Level
+		want  string
+	}{
+		{"default", nil, "ERROR"},
+		{"info", &info, "INFO"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			c := New(true).SetTraceOptions(TraceOptions{
+				Logger:     slog.New(slog.NewJSONHandler(&buf, nil)),
+				ErrorLevel: tt.level,
+			})
+			if _, err := c.Get(context.Background(), "http://"+addr, nil); err == nil {
+				t.Fatal("want connect error")
+			}
+			failed := 0
+			for _, rec := range traceRecords(t, &buf) {
+				if rec["error"] != nil {
+					failed++
+					if rec["level"] != tt.want {
+						t.Errorf("want level %s, got %v", tt.want, rec["level"])
+					}
+				}
+			}
+			if failed == 0 {
+				t.Fatal("no record of failed phase")
+			}
+		})
+	}
+}
@@ -0,0 +1,2 @@
+// Package httpxtest contains programmable mock transport and server for testing code built on httpx
+package httpxtest
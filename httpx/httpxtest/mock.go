@@ -0,0 +1,362 @@
+package httpxtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// ErrUnexpectedRequest is returned by mock transport for request without matching expectation.
+var ErrUnexpectedRequest = errors.New("httpxtest: unexpected request")
+
+// Mock is programmable [net/http.RoundTripper] and [net/http.Handler]. Expectations are
+// registered with [Mock.On] and unmet expectations fail the test once it's completed.
+//
+//	m := httpxtest.NewMock(t)
+//	m.On(http.MethodGet, "/users").Query("page", "1").ReplyJSON(http.StatusOK, users)
+//	c := httpx.New(false).SetTransport(m)
+type Mock struct {
+	t            testing.TB
+	mu           sync.Mutex
+	expectations []*Expectation
+	calls        []Call
+	ordered      bool
+}
+
+// Call is the request received by mock.
+type Call struct {
+	Method string
+	Path   string
+	Query  string
+	Header http.Header
+	Body   []byte
+	// Expectation is the matched expectation, nil for unexpected calls
+	Expectation *Expectation
+}
+
+// NewMock returns mock which asserts all expectations are met on test cleanup.
+func NewMock(t testing.TB) *Mock {
+	m := &Mock{t: t}
+	t.Cleanup(m.AssertExpectations)
+	return m
+}
+
+// InOrder requires expectations to be met in the order they are registered.
+func (m *Mock) InOrder() *Mock {
+	m.mu.Lock()
+	m.ordered = true
+	m.mu.Unlock()
+	return m
+}
+
+// On registers expectation for request with method and url path,
+// by default expectation must be met exactly once and replies with 200 and empty body.
+func (m *Mock) On(method, path string) *Expectation {
+	e := &Expectation{
+		method:  method,
+		path:    path,
+		query:   make(map[string]string),
+		headers: make(map[string]string),
+		status:  http.StatusOK,
+		header:  make(http.Header),
+		times:   1,
+	}
+	m.mu.Lock()
+	m.expectations = append(m.expectations, e)
+	m.mu.Unlock()
+	return e
+}
+
+// Calls returns all received requests in order.
+func (m *Mock) Calls() []Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Call(nil), m.calls...)
+}
+
+// AssertExpectations fails the test if any expectation is not met.
+// It's called automatically on test cleanup.
+func (m *Mock) AssertExpectations() {
+	m.t.Helper()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, e := range m.expectations {
+		if e.times >= 0 && e.calls < e.times {
+			m.t.Errorf("httpxtest: unmet expectation %s, called %d of %d times", e, e.calls, e.times)
+		}
+	}
+}
+
+// AssertCalled fails the test if expectation for method and path was not called n times.
+func (m *Mock) AssertCalled(method, path string, n int) {
+	m.t.Helper()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	got := 0
+	for _, c := range m.calls {
+		if c.Method == method && c.Path == path {
+			got++
+		}
+	}
+	if got != n {
+		m.t.Errorf("httpxtest: wanted %s %s to be called %d times got %d", method, path, n, got)
+	}
+}
+
+func (m *Mock) RoundTrip(req *http.Request) (*http.Response, error) {
+	e, err := m.match(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.wait(req); err != nil {
+		return nil, err
+	}
+	if e.err != nil {
+		return nil, e.err
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", e.status, http.StatusText(e.status)),
+		StatusCode:    e.status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        e.header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(e.body)),
+		ContentLength: int64(len(e.body)),
+		Request:       req,
+	}, nil
+}
+
+// ServeHTTP serves the expectations so mock can be used with [net/http/httptest.Server].
+// Unexpected requests are replied with 501 Not Implemented and expectations
+// with error are replied with 502 Bad Gateway.
+func (m *Mock) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	e, err := m.match(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotImplemented)
+		return
+	}
+	if err := e.wait(r); err != nil {
+		return
+	}
+	if e.err != nil {
+		http.Error(w, e.err.Error(), http.StatusBadGateway)
+		return
+	}
+	for k, v := range e.header {
+		w.Header()[k] = v
+	}
+	w.WriteHeader(e.status)
+	_, _ = w.Write(e.body)
+}
+
+// Server starts [net/http/httptest.Server] serving the mock which is closed on test cleanup.
+func (m *Mock) Server() *httptest.Server {
+	ts := httptest.NewServer(m)
+	m.t.Cleanup(ts.Close)
+	return ts
+}
+
+// match records the call and returns its expectation.
+func (m *Mock) match(req *http.Request) (*Expectation, error) {
+	var body []byte
+	if req.Body != nil {
+		body, _ = io.ReadAll(req.Body)
+		req.Body.Close()
+	}
+	call := Call{
+		Method: req.Method,
+		Path:   req.URL.Path,
+		Query:  req.URL.RawQuery,
+		Header: req.Header.Clone(),
+		Body:   body,
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, e := range m.expectations {
+		if e.times >= 0 && e.calls >= e.times {
+			continue
+		}
+		if e.matches(req, body) {
+			e.calls++
+			call.Expectation = e
+			m.calls = append(m.calls, call)
+			return e, nil
+		}
+		if m.ordered && e.times >= 0 {
+			m.calls = append(m.calls, call)
+			m.t.Errorf("httpxtest: out of order request %s %s, expected %s", req.Method, req.URL, e)
+			return nil, fmt.Errorf("%w: %s %s", ErrUnexpectedRequest, req.Method, req.URL)
+		}
+	}
+	m.calls = append(m.calls, call)
+	m.t.Errorf("httpxtest: unexpected request %s %s", req.Method, req.URL)
+	return nil, fmt.Errorf("%w: %s %s", ErrUnexpectedRequest, req.Method, req.URL)
+}
+
+// Expectation is expected request with its canned response.
+type Expectation struct {
+	method  string
+	path    string
+	query   map[string]string
+	headers map[string]string
+	body    []byte
+	bodyFn  func([]byte) bool
+	status  int
+	header  http.Header
+	delay   time.Duration
+	err     error
+	times   int
+	calls   int
+}
+
+func (e *Expectation) String() string {
+	return e.method + " " + e.path
+}
+
+// Query requires query parameter k to have value v.
+func (e *Expectation) Query(k, v string) *Expectation {
+	e.query[k] = v
+	return e
+}
+
+// Header requires request header k to have value v.
+func (e *Expectation) Header(k, v string) *Expectation {
+	e.headers[k] = v
+	return e
+}
+
+// Body requires request body to satisfy matcher.
+func (e *Expectation) Body(matcher func(body []byte) bool) *Expectation {
+	e.bodyFn = matcher
+	return e
+}
+
+// BodyString requires request body to be equal to s.
+func (e *Expectation) BodyString(s string) *Expectation {
+	return e.Body(func(b []byte) bool { return string(b) == s })
+}
+
+// BodyJSON requires request body to be JSON semantically equal to v.
+func (e *Expectation) BodyJSON(v any) *Expectation {
+	want, err := normalizeJSON(v)
+	if err != nil {
+		panic(fmt.Sprintf("httpxtest: invalid JSON body: %v", err))
+	}
+	return e.Body(func(b []byte) bool {
+		var got any
+		if err := json.Unmarshal(b, &got); err != nil {
+			return false
+		}
+		return reflect.DeepEqual(want, got)
+	})
+}
+
+// Reply sets the status and body of canned response.
+func (e *Expectation) Reply(status int, body string) *Expectation {
+	e.status = status
+	e.body = []byte(body)
+	return e
+}
+
+// ReplyJSON sets the status and JSON encoded body of canned response.
+func (e *Expectation) ReplyJSON(status int, v any) *Expectation {
+	b, err := json.Marshal(v)
+	if err != nil {
+		panic(fmt.Sprintf("httpxtest: invalid JSON reply: %v", err))
+	}
+	e.header.Set("Content-Type", "application/json")
+	e.status = status
+	e.body = b
+	return e
+}
+
+// ReplyHeader sets header of canned response.
+func (e *Expectation) ReplyHeader(k, v string) *Expectation {
+	e.header.Set(k, v)
+	return e
+}
+
+// Delay delays the response, request context cancellation is honoured.
+func (e *Expectation) Delay(d time.Duration) *Expectation {
+	e.delay = d
+	return e
+}
+
+// Error makes transport fail with err instead of replying.
+func (e *Expectation) Error(err error) *Expectation {
+	e.err = err
+	return e
+}
+
+// Times sets how many times expectation must be met.
+func (e *Expectation) Times(n int) *Expectation {
+	e.times = n
+	return e
+}
+
+// AnyTimes allows expectation to be met any number of times including zero.
+func (e *Expectation) AnyTimes() *Expectation {
+	e.times = -1
+	return e
+}
+
+func (e *Expectation) matches(req *http.Request, body []byte) bool {
+	if e.method != req.Method || e.path != req.URL.Path {
+		return false
+	}
+	q := req.URL.Query()
+	for k, v := range e.query {
+		if !q.Has(k) || q.Get(k) != v {
+			return false
+		}
+	}
+	for k, v := range e.headers {
+		if req.Header.Get(k) != v {
+			return false
+		}
+	}
+	return e.bodyFn == nil || e.bodyFn(body)
+}
+
+func (e *Expectation) wait(req *http.Request) error {
+	if e.delay <= 0 {
+		return nil
+	}
+	t := time.NewTimer(e.delay)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-req.Context().Done():
+		return req.Context().Err()
+	}
+}
+
+func normalizeJSON(v any) (any, error) {
+	var b []byte
+	switch v := v.(type) {
+	case string:
+		b = []byte(strings.TrimSpace(v))
+	case []byte:
+		b = v
+	default:
+		var err error
+		if b, err = json.Marshal(v); err != nil {
+			return nil, err
+		}
+	}
+	var out any
+	err := json.Unmarshal(b, &out)
+	return out, err
+}
@@ -0,0 +1,94 @@
+package httpxtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"collections/httpx"
+)
+
+// fakeTB records failures instead of failing the test
+type fakeTB struct {
+	testing.TB
+	errors   []string
+	cleanups []func()
+}
+
+func (f *fakeTB) Helper()           {}
+func (f *fakeTB) Cleanup(fn func()) { f.cleanups = append(f.cleanups, fn) }
+func (f *fakeTB) Errorf(format string, args ...any) {
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+func TestMock(t *testing.T) {
+	m := NewMock(t).InOrder()
+	m.On(http.MethodPost, "/users").
+		BodyJSON(`{"name": "a"}`).
+		ReplyJSON(http.StatusCreated, map[string]int{"id": 1})
+	m.On(http.MethodGet, "/users").Query("page", "1").Header("X-Token", "t").Reply(http.StatusOK, "[]").Times(2)
+
+	c := httpx.New(false).SetTransport(m)
+	res, err := c.Post(context.Background(), "http://api.test/users", strings.NewReader(`{"name":"a"}`), nil)
+	if err != nil || res.StatusCode != http.StatusCreated {
+		t.Fatalf("wanted 201 got %v, %v", res, err)
+	}
+	ho := httpx.NewHTTPOptions().Query("page", "1").Header("X-Token", "t")
+	for range 2 {
+		res, err = c.Get(context.Background(), "http://api.test/users", ho)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := io.ReadAll(res.Body)
+		res.Body.Close()
+		if string(b) != "[]" {
+			t.Fatalf("wanted [] got %s", b)
+		}
+	}
+	m.AssertCalled(http.MethodGet, "/users", 2)
+}
+
+func TestMockServer(t *testing.T) {
+	m := NewMock(t)
+	m.On(http.MethodGet, "/slow").Delay(time.Second).AnyTimes()
+	m.On(http.MethodGet, "/fail").Error(errors.New("boom"))
+	ts := m.Server()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := httpx.New(false).Get(ctx, ts.URL+"/slow", nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("wanted deadline exceeded got %v", err)
+	}
+	res, err := httpx.New(false).Get(context.Background(), ts.URL+"/fail", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusBadGateway {
+		t.Fatalf("wanted 502 got %d", res.StatusCode)
+	}
+}
+
+func TestMockFailures(t *testing.T) {
+	tb := &fakeTB{TB: t}
+	m := NewMock(tb).InOrder()
+	m.On(http.MethodGet, "/first")
+	m.On(http.MethodGet, "/second")
+
+	c := httpx.New(false).SetTransport(m)
+	if _, err := c.Get(context.Background(), "http://api.test/second", nil); !errors.Is(err, ErrUnexpectedRequest) {
+		t.Fatalf("wanted unexpected request got %v", err)
+	}
+	for _, fn := range tb.cleanups {
+		fn()
+	}
+	// one out of order request and two unmet expectations
+	if len(tb.errors) != 3 {
+		t.Fatalf("wanted 3 failures got %q", tb.errors)
+	}
+}
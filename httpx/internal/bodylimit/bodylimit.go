@@ -0,0 +1,63 @@
+// Package bodylimit limits size of bodies read through it, it's shared by httpx and its
+// subpackages so they report same error without depending on each other.
+package bodylimit
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrTooLarge is matched by [Error] with [errors.Is].
+var ErrTooLarge = errors.New("body too large")
+
+// Error is returned by reads of body which exceeded the limit.
+type Error struct {
+	Limit int64
+	// ContentLength is the declared size of body, -1 if unknown
+	ContentLength int64
+}
+
+func (e Error) Error() string {
+	return fmt.Sprintf("body too large limit=%d, content_length=%d", e.Limit, e.ContentLength)
+}
+
+func (e Error) Is(target error) bool {
+	return target == ErrTooLarge
+}
+
+// Reader returns body which fails with [Error] once more than limit bytes are read from rc.
+func Reader(rc io.ReadCloser, limit, contentLength int64) io.ReadCloser {
+	return &reader{ReadCloser: rc, remaining: limit, limit: limit, contentLength: contentLength}
+}
+
+type reader struct {
+	io.ReadCloser
+	remaining     int64
+	limit         int64
+	contentLength int64
+	err           error
+}
+
+func (b *reader) Read(p []byte) (int, error) {
+	if b.err != nil {
+		return 0, b.err
+	}
+	if b.contentLength > b.limit {
+		b.err = Error{Limit: b.limit, ContentLength: b.contentLength}
+		return 0, b.err
+	}
+	// one extra byte tells whether body exceeds the limit
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+	n, err := b.ReadCloser.Read(p)
+	if int64(n) > b.remaining {
+		n = int(b.remaining)
+		b.remaining = 0
+		b.err = Error{Limit: b.limit, ContentLength: b.contentLength}
+		return n, b.err
+	}
+	b.remaining -= int64(n)
+	return n, err
+}
@@ -0,0 +1,71 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// BatchElem is single call of batch. Result must be pointer the result is decoded into, nil
+// result discards it. Err is set to error object of the call or [ErrMissingResponse].
+type BatchElem struct {
+	Method string
+	Params any
+	Result any
+	// Notify sends the call as notification, server doesn't respond to it
+	Notify bool
+	Err    error
+}
+
+// Batch sends calls as single batch request and correlates responses to calls by their ids,
+// responses may come in any order. Returned error is transport error or error object of the
+// whole batch, errors of single calls are set to their Err.
+func (c *Client) Batch(ctx context.Context, elems []BatchElem) error {
+	if len(elems) == 0 {
+		return nil
+	}
+	reqs := make([]request, len(elems))
+	byID := make(map[string]*BatchElem, len(elems))
+	for i := range elems {
+		e := &elems[i]
+		e.Err = nil
+		reqs[i] = request{JSONRPC: version, Method: e.Method, Params: e.Params}
+		if !e.Notify {
+			reqs[i].ID = c.nextID()
+			byID[string(reqs[i].ID)] = e
+		}
+	}
+
+	body, err := c.send(ctx, reqs)
+	if err != nil {
+		return err
+	}
+	// server responds with nothing when batch contains only notifications
+	if len(byID) == 0 {
+		return nil
+	}
+
+	var res []response
+	if err := json.Unmarshal(body, &res); err != nil {
+		// batch which couldn't be parsed gets single error response
+		var single response
+		if json.Unmarshal(body, &single) == nil && single.Error != nil {
+			return *single.Error
+		}
+		return fmt.Errorf("jsonrpc: failed to decode batch response: %w", err)
+	}
+	for i := range res {
+		e, ok := byID[string(res[i].ID)]
+		if !ok {
+			continue
+		}
+		delete(byID, string(res[i].ID))
+		if e.Err = res[i].err(); e.Err == nil && e.Result != nil {
+			e.Err = json.Unmarshal(res[i].Result, e.Result)
+		}
+	}
+	for _, e := range byID {
+		e.Err = ErrMissingResponse
+	}
+	return nil
+}
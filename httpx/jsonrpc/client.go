@@ -0,0 +1,135 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+
+	"collections/httpx"
+)
+
+const (
+	version      = "2.0"
+	maxErrorBody = 4096
+)
+
+// Options configures the [Client].
+type Options struct {
+	// HTTPOptions returns options of every request, it can be used for setting auth headers,
+	// retry or timeout hooks. Content-Type and Accept headers are set by client.
+	HTTPOptions func() *httpx.HTTPOptions
+}
+
+// Client calls JSON-RPC 2.0 methods over http, request ids are generated by client.
+type Client struct {
+	c        *httpx.Client
+	endpoint string
+	opts     Options
+	id       atomic.Uint64
+}
+
+// New returns client sending requests to endpoint.
+func New(c *httpx.Client, endpoint string, opts Options) *Client {
+	return &Client{c: c, endpoint: endpoint, opts: opts}
+}
+
+// request is request or notification when id is nil
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  any             `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result"`
+	Error   *Error          `json:"error"`
+}
+
+// err returns error of response or nil when it has result
+func (r *response) err() error {
+	switch {
+	case r.Error != nil:
+		return *r.Error
+	case r.Result == nil:
+		return ErrInvalidResponse
+	}
+	return nil
+}
+
+// Call calls method with params and decodes its result into T. Params must encode to JSON
+// array or object, nil params are omitted. Error object of response is returned as [Error].
+func Call[T any](ctx context.Context, c *Client, method string, params any) (T, error) {
+	var out T
+	err := c.Call(ctx, method, params, &out)
+	return out, err
+}
+
+// Call calls method with params and decodes its result into result, nil result discards it.
+func (c *Client) Call(ctx context.Context, method string, params, result any) error {
+	req := request{JSONRPC: version, ID: c.nextID(), Method: method, Params: params}
+	body, err := c.send(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	var res response
+	if err := json.Unmarshal(body, &res); err != nil {
+		return fmt.Errorf("jsonrpc: failed to decode response: %w", err)
+	}
+	if err := res.err(); err != nil {
+		return err
+	}
+	if !bytes.Equal(res.ID, req.ID) {
+		return fmt.Errorf("%w: id=%s, want=%s", ErrInvalidResponse, res.ID, req.ID)
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(res.Result, result)
+}
+
+// Notify sends notification, server doesn't respond to it so only transport errors are returned.
+func (c *Client) Notify(ctx context.Context, method string, params any) error {
+	_, err := c.send(ctx, request{JSONRPC: version, Method: method, Params: params})
+	return err
+}
+
+func (c *Client) nextID() json.RawMessage {
+	return strconv.AppendUint(nil, c.id.Add(1), 10)
+}
+
+// send posts the payload and returns body of response, body is empty for notifications.
+func (c *Client) send(ctx context.Context, payload any) ([]byte, error) {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	var ho *httpx.HTTPOptions
+	if c.opts.HTTPOptions != nil {
+		ho = c.opts.HTTPOptions()
+	}
+	if ho == nil {
+		ho = httpx.NewHTTPOptions()
+	}
+	ho.Header("Content-Type", "application/json").Header("Accept", "application/json")
+
+	res, err := c.c.Post(ctx, c.endpoint, bytes.NewReader(b), ho)
+	body, err := httpx.Bytes(res, err)
+	if err != nil {
+		return nil, err
+	}
+	// servers may respond with error object along with non 2xx status
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		var r response
+		if json.Unmarshal(body, &r) != nil || r.Error == nil {
+			return nil, StatusError{StatusCode: res.StatusCode, Body: string(body[:min(len(body), maxErrorBody)])}
+		}
+	}
+	return body, nil
+}
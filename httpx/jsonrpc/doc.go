@@ -0,0 +1,3 @@
+// Package jsonrpc contains JSON-RPC 2.0 client over http built on httpx client with typed calls,
+// notifications and batches
+package jsonrpc
@@ -0,0 +1,56 @@
+package jsonrpc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Error codes defined by the specification, codes from -32000 to -32099 are reserved
+// for implementation defined server errors.
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+)
+
+var (
+	// ErrMissingResponse is the error of batch call which has no response in the batch.
+	ErrMissingResponse = errors.New("jsonrpc: missing response")
+	// ErrInvalidResponse is returned when response is neither result nor error.
+	ErrInvalidResponse = errors.New("jsonrpc: invalid response")
+)
+
+// Error is the error object of response.
+type Error struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (e Error) Error() string {
+	if len(e.Data) == 0 {
+		return fmt.Sprintf("jsonrpc: code=%d, message=%s", e.Code, e.Message)
+	}
+	return fmt.Sprintf("jsonrpc: code=%d, message=%s, data=%s", e.Code, e.Message, e.Data)
+}
+
+// DecodeData decodes data of error into v.
+func (e Error) DecodeData(v any) error {
+	if len(e.Data) == 0 {
+		return errors.New("jsonrpc: error has no data")
+	}
+	return json.Unmarshal(e.Data, v)
+}
+
+// StatusError is returned when server responds with non 2xx status without JSON-RPC response.
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e StatusError) Error() string {
+	return fmt.Sprintf("jsonrpc: unexpected response status=%d, body=%s", e.StatusCode, e.Body)
+}
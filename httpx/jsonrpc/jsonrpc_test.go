@@ -0,0 +1,155 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync/atomic"
+	"testing"
+
+	"collections/httpx"
+)
+
+// handle implements sum and echo methods
+func handle(req request) *response {
+	if req.ID == nil {
+		return nil
+	}
+	res := &response{JSONRPC: version, ID: req.ID}
+	switch req.Method {
+	case "sum":
+		var nums []int
+		b, _ := json.Marshal(req.Params)
+		if err := json.Unmarshal(b, &nums); err != nil {
+			res.Error = &Error{Code: CodeInvalidParams, Message: "invalid params", Data: json.RawMessage(`"numbers expected"`)}
+			return res
+		}
+		sum := 0
+		for _, n := range nums {
+			sum += n
+		}
+		res.Result, _ = json.Marshal(sum)
+	default:
+		res.Error = &Error{Code: CodeMethodNotFound, Message: "method not found"}
+	}
+	return res
+}
+
+func server(t *testing.T, notified *atomic.Int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var batch []request
+		if json.Unmarshal(body, &batch) == nil {
+			var out []*response
+			for _, req := range batch {
+				if res := handle(req); res != nil {
+					out = append(out, res)
+				} else {
+					notified.Add(1)
+				}
+			}
+			if len(out) == 0 {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			// responses may come in any order
+			slices.Reverse(out)
+			json.NewEncoder(w).Encode(out)
+			return
+		}
+		var req request
+		if err := json.Unmarshal(body, &req); err != nil {
+			t.Error(err)
+			return
+		}
+		if req.JSONRPC != version {
+			t.Errorf("unexpected version %q", req.JSONRPC)
+		}
+		res := handle(req)
+		if res == nil {
+			notified.Add(1)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		json.NewEncoder(w).Encode(res)
+	}))
+}
+
+func TestCall(t *testing.T) {
+	var notified atomic.Int32
+	srv := server(t, &notified)
+	defer srv.Close()
+	c := New(httpx.New(false), srv.URL, Options{})
+	ctx := context.Background()
+
+	sum, err := Call[int](ctx, c, "sum", []int{1, 2, 3})
+	if err != nil || sum != 6 {
+		t.Fatalf("got %d, err=%v", sum, err)
+	}
+
+	_, err = Call[int](ctx, c, "sum", map[string]int{"a": 1})
+	var rpcErr Error
+	if !errors.As(err, &rpcErr) || rpcErr.Code != CodeInvalidParams {
+		t.Fatalf("unexpected error %v", err)
+	}
+	var data string
+	if err := rpcErr.DecodeData(&data); err != nil || data != "numbers expected" {
+		t.Fatalf("got data %q, err=%v", data, err)
+	}
+
+	if err := c.Notify(ctx, "log", []string{"hello"}); err != nil {
+		t.Fatal(err)
+	}
+	if notified.Load() != 1 {
+		t.Fatal("notification not received")
+	}
+}
+
+func TestBatch(t *testing.T) {
+	var notified atomic.Int32
+	srv := server(t, &notified)
+	defer srv.Close()
+	c := New(httpx.New(false), srv.URL, Options{})
+
+	var a, b int
+	elems := []BatchElem{
+		{Method: "sum", Params: []int{1, 2}, Result: &a},
+		{Method: "log", Params: []string{"hello"}, Notify: true},
+		{Method: "sum", Params: []int{3, 4}, Result: &b},
+		{Method: "unknown"},
+	}
+	if err := c.Batch(context.Background(), elems); err != nil {
+		t.Fatal(err)
+	}
+	if a != 3 || b != 7 || elems[0].Err != nil || elems[1].Err != nil || elems[2].Err != nil {
+		t.Fatalf("unexpected results a=%d, b=%d, elems=%+v", a, b, elems)
+	}
+	var rpcErr Error
+	if !errors.As(elems[3].Err, &rpcErr) || rpcErr.Code != CodeMethodNotFound {
+		t.Fatalf("unexpected error %v", elems[3].Err)
+	}
+	if notified.Load() != 1 {
+		t.Fatal("notification not received")
+	}
+
+	if err := c.Batch(context.Background(), []BatchElem{{Method: "log", Notify: true}}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStatusError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	_, err := Call[int](context.Background(), New(httpx.New(false), srv.URL, Options{}), "sum", []int{1})
+	var se StatusError
+	if !errors.As(err, &se) || se.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
@@ -0,0 +1,162 @@
+package httpx
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"collections/httpx/metrics"
+)
+
+// BreakerState is the state of circuit breaker reported through [Metrics.SetBreakerState].
+type BreakerState int
+
+const (
+	BreakerClosed BreakerState = iota
+	BreakerHalfOpen
+	BreakerOpen
+)
+
+// Metrics records runtime metrics of [Client] labelled by host and method.
+// Every attempt including retries and redirects is recorded as separate request.
+type Metrics struct {
+	requests *metrics.Counter
+	errors   *metrics.Counter
+	retries  *metrics.Counter
+	duration *metrics.Histogram
+	inFlight *metrics.Gauge
+	bytesOut *metrics.Counter
+	bytesIn  *metrics.Counter
+	conns    *metrics.Counter
+	breaker  *metrics.Gauge
+}
+
+// NewMetrics registers client metrics in reg. Metric names are prefixed with "httpx_"
+// so registry can only hold metrics of single client, use [NewMetricsWithNamespace]
+// for registering multiple clients in same registry.
+func NewMetrics(reg *metrics.Registry) *Metrics {
+	return NewMetricsWithNamespace(reg, "httpx")
+}
+
+// NewMetricsWithNamespace registers client metrics in reg with namespace as metric name prefix.
+func NewMetricsWithNamespace(reg *metrics.Registry, namespace string) *Metrics {
+	n := namespace + "_"
+	return &Metrics{
+		requests: reg.NewCounter(
+			n+"requests_total",
+			"Total number of completed requests by status class.",
+			"host", "method", "status_class",
+		),
+		errors: reg.NewCounter(
+			n+"request_errors_total",
+			"Total number of requests failed without response.",
+			"host", "method",
+		),
+		retries: reg.NewCounter(
+			n+"retries_total",
+			"Total number of retry attempts.",
+			"host", "method",
+		),
+		duration: reg.NewHistogram(
+			n+"request_duration_seconds",
+			"Time till response headers are received.",
+			metrics.DefaultBuckets,
+			"host", "method",
+		),
+		inFlight: reg.NewGauge(
+			n+"requests_in_flight",
+			"Number of requests whose response body is not yet consumed.",
+			"host",
+		),
+		bytesOut: reg.NewCounter(
+			n+"request_bytes_total",
+			"Total number of request body bytes sent.",
+			"host", "method",
+		),
+		bytesIn: reg.NewCounter(
+			n+"response_bytes_total",
+			"Total number of response body bytes read.",
+			"host", "method",
+		),
+		conns: reg.NewCounter(
+			n+"connections_total",
+			"Total number of acquired connections by pool reuse.",
+			"host", "reused",
+		),
+		breaker: reg.NewGauge(
+			n+"circuit_breaker_state",
+			"State of circuit breaker, 0 closed, 1 half-open and 2 open.",
+			"host",
+		),
+	}
+}
+
+// SetBreakerState records state of circuit breaker guarding host, breaker calls it on every
+// state change.
+func (m *Metrics) SetBreakerState(host string, state BreakerState) {
+	m.breaker.Set(float64(state), host)
+}
+
+// begin records start of round trip and returns request with counted body and trace for
+// connection reuse along with the funcs to be called with response and once it is consumed.
+func (m *Metrics) begin(req *http.Request, retry bool) (*http.Request, func(*http.Response, error), func()) {
+	host, method := req.URL.Host, req.Method
+	start := time.Now()
+	if retry {
+		m.retries.Inc(host, method)
+	}
+	m.inFlight.Add(1, host)
+
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			m.conns.Inc(host, strconv.FormatBool(info.Reused))
+		},
+	}))
+	out := &countingReader{}
+	if req.Body != nil && req.Body != http.NoBody {
+		out.ReadCloser = req.Body
+		req.Body = out
+	}
+
+	in := &countingReader{}
+	gotResponse := func(res *http.Response, err error) {
+		m.bytesOut.Add(float64(out.n.Load()), host, method)
+		if err != nil {
+			m.errors.Inc(host, method)
+			return
+		}
+		m.duration.Observe(time.Since(start).Seconds(), host, method)
+		m.requests.Inc(host, method, statusClass(res.StatusCode))
+		if res.Body != nil {
+			in.ReadCloser = res.Body
+			res.Body = in
+		}
+	}
+	done := func() {
+		m.inFlight.Add(-1, host)
+		m.bytesIn.Add(float64(in.n.Load()), host, method)
+	}
+	return req, gotResponse, done
+}
+
+func statusClass(code int) string {
+	if code < 100 || code > 599 {
+		return "unknown"
+	}
+	return strconv.Itoa(code/100) + "xx"
+}
+
+// countingReader counts bytes read through it.
+type countingReader struct {
+	io.ReadCloser
+	n atomic.Int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.n.Add(int64(n))
+	return n, err
+}
@@ -0,0 +1,3 @@
+// Package metrics contains dependency free counters, gauges and histograms with labels
+// which can be served in prometheus text exposition format and exported through expvar
+package metrics
@@ -0,0 +1,168 @@
+package metrics
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets are the default histogram buckets in seconds suitable for request latencies.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+const (
+	typeCounter   = "counter"
+	typeGauge     = "gauge"
+	typeHistogram = "histogram"
+)
+
+// family is the metric with all of its labelled series.
+type family struct {
+	mu      sync.Mutex
+	name    string
+	help    string
+	typ     string
+	labels  []string
+	buckets []float64
+	series  map[string]*series
+}
+
+type series struct {
+	values []string
+	value  float64 // counter and gauge value, histogram sum
+	count  uint64  // histogram observations
+	counts []uint64
+}
+
+// get returns series for label values and creates it if missing.
+// caller must hold f.mu
+func (f *family) get(values []string) *series {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf(
+			"metrics: %s expects %d label values but got %d",
+			f.name,
+			len(f.labels),
+			len(values),
+		))
+	}
+	s, ok := f.find(values)
+	if !ok {
+		s = &series{values: slices.Clone(values)}
+		if f.typ == typeHistogram {
+			s.counts = make([]uint64, len(f.buckets))
+		}
+		f.series[strings.Join(values, "\xff")] = s
+	}
+	return s
+}
+
+// find returns series for label values without creating it.
+// caller must hold f.mu
+func (f *family) find(values []string) (*series, bool) {
+	s, ok := f.series[strings.Join(values, "\xff")]
+	return s, ok
+}
+
+// sorted returns copy of all series sorted by label values.
+func (f *family) sorted() []series {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	out := make([]series, 0, len(f.series))
+	for _, s := range f.series {
+		c := *s
+		c.counts = slices.Clone(s.counts)
+		out = append(out, c)
+	}
+	slices.SortFunc(out, func(a, b series) int {
+		return slices.Compare(a.values, b.values)
+	})
+	return out
+}
+
+// Counter is monotonically increasing value partitioned by labels.
+type Counter struct {
+	f *family
+}
+
+// Inc increments the counter of provided label values by one.
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds v to the counter of provided label values. Negative values are ignored.
+func (c *Counter) Add(v float64, labelValues ...string) {
+	if v < 0 {
+		return
+	}
+	c.f.mu.Lock()
+	c.f.get(labelValues).value += v
+	c.f.mu.Unlock()
+}
+
+// Value returns current value of the counter for provided label values.
+func (c *Counter) Value(labelValues ...string) float64 {
+	c.f.mu.Lock()
+	defer c.f.mu.Unlock()
+	if s, ok := c.f.find(labelValues); ok {
+		return s.value
+	}
+	return 0
+}
+
+// Gauge is value partitioned by labels which can go up and down.
+type Gauge struct {
+	f *family
+}
+
+// Set sets the gauge of provided label values to v.
+func (g *Gauge) Set(v float64, labelValues ...string) {
+	g.f.mu.Lock()
+	g.f.get(labelValues).value = v
+	g.f.mu.Unlock()
+}
+
+// Add adds v to the gauge of provided label values, v can be negative.
+func (g *Gauge) Add(v float64, labelValues ...string) {
+	g.f.mu.Lock()
+	g.f.get(labelValues).value += v
+	g.f.mu.Unlock()
+}
+
+// Value returns current value of the gauge for provided label values.
+func (g *Gauge) Value(labelValues ...string) float64 {
+	g.f.mu.Lock()
+	defer g.f.mu.Unlock()
+	if s, ok := g.f.find(labelValues); ok {
+		return s.value
+	}
+	return 0
+}
+
+// Histogram counts observations in configured buckets partitioned by labels.
+type Histogram struct {
+	f *family
+}
+
+// Observe adds single observation to the histogram of provided label values.
+func (h *Histogram) Observe(v float64, labelValues ...string) {
+	h.f.mu.Lock()
+	s := h.f.get(labelValues)
+	for i, le := range h.f.buckets {
+		if v <= le {
+			s.counts[i]++
+		}
+	}
+	s.count++
+	s.value += v
+	h.f.mu.Unlock()
+}
+
+// Count returns number of observations and their sum for provided label values.
+func (h *Histogram) Count(labelValues ...string) (uint64, float64) {
+	h.f.mu.Lock()
+	defer h.f.mu.Unlock()
+	if s, ok := h.f.find(labelValues); ok {
+		return s.count, s.value
+	}
+	return 0, 0
+}
@@ -0,0 +1,190 @@
+package metrics
+
+import (
+	"bufio"
+	"expvar"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType is the content type of prometheus text exposition format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Registry holds metric families and exposes them in prometheus text format and expvar.
+type Registry struct {
+	mu       sync.Mutex
+	families []*family
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// NewCounter registers new counter with provided label names.
+// It panics if metric with same name is already registered.
+func (r *Registry) NewCounter(name, help string, labels ...string) *Counter {
+	return &Counter{f: r.register(name, help, typeCounter, labels, nil)}
+}
+
+// NewGauge registers new gauge with provided label names.
+// It panics if metric with same name is already registered.
+func (r *Registry) NewGauge(name, help string, labels ...string) *Gauge {
+	return &Gauge{f: r.register(name, help, typeGauge, labels, nil)}
+}
+
+// NewHistogram registers new histogram with provided upper bounds of buckets and label names.
+// If buckets is empty [DefaultBuckets] will be used.
+// It panics if metric with same name is already registered.
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	buckets = slices.Clone(buckets)
+	slices.Sort(buckets)
+	return &Histogram{f: r.register(name, help, typeHistogram, labels, buckets)}
+}
+
+func (r *Registry) register(name, help, typ string, labels []string, buckets []float64) *family {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, f := range r.families {
+		if f.name == name {
+			panic("metrics: duplicate metric " + name)
+		}
+	}
+	f := &family{
+		name:    name,
+		help:    help,
+		typ:     typ,
+		labels:  slices.Clone(labels),
+		buckets: buckets,
+		series:  make(map[string]*series),
+	}
+	r.families = append(r.families, f)
+	return f
+}
+
+func (r *Registry) snapshot() []*family {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.families)
+}
+
+// WritePrometheus writes all metrics in prometheus text exposition format.
+func (r *Registry) WritePrometheus(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for _, f := range r.snapshot() {
+		fmt.Fprintf(bw, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+		fmt.Fprintf(bw, "# TYPE %s %s\n", f.name, f.typ)
+		for _, s := range f.sorted() {
+			if f.typ != typeHistogram {
+				writeSample(bw, f.name, f.labels, s.values, "", s.value)
+				continue
+			}
+			for i, le := range f.buckets {
+				writeSample(bw, f.name+"_bucket", f.labels, s.values, formatFloat(le), float64(s.counts[i]))
+			}
+			writeSample(bw, f.name+"_bucket", f.labels, s.values, "+Inf", float64(s.count))
+			writeSample(bw, f.name+"_sum", f.labels, s.values, "", s.value)
+			writeSample(bw, f.name+"_count", f.labels, s.values, "", float64(s.count))
+		}
+	}
+	return bw.Flush()
+}
+
+// Handler returns [net/http.Handler] which serves metrics in prometheus text exposition format.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		_ = r.WritePrometheus(w)
+	})
+}
+
+// Publish exports all metrics under name through [expvar].
+// Like [expvar.Publish] it panics if name is already published.
+func (r *Registry) Publish(name string) {
+	expvar.Publish(name, expvar.Func(r.expvar))
+}
+
+// expvar returns metrics as map of metric name to its series.
+func (r *Registry) expvar() any {
+	out := make(map[string]any)
+	for _, f := range r.snapshot() {
+		series := make([]map[string]any, 0)
+		for _, s := range f.sorted() {
+			labels := make(map[string]string, len(f.labels))
+			for i, l := range f.labels {
+				labels[l] = s.values[i]
+			}
+			m := map[string]any{"labels": labels}
+			if f.typ == typeHistogram {
+				buckets := make(map[string]uint64, len(f.buckets))
+				for i, le := range f.buckets {
+					buckets[formatFloat(le)] = s.counts[i]
+				}
+				m["buckets"] = buckets
+				m["sum"] = s.value
+				m["count"] = s.count
+			} else {
+				m["value"] = s.value
+			}
+			series = append(series, m)
+		}
+		out[f.name] = series
+	}
+	return out
+}
+
+func writeSample(w *bufio.Writer, name string, labels, values []string, le string, v float64) {
+	w.WriteString(name)
+	if len(labels) > 0 || le != "" {
+		w.WriteByte('{')
+		for i, l := range labels {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			fmt.Fprintf(w, "%s=\"%s\"", l, escapeLabel(values[i]))
+		}
+		if le != "" {
+			if len(labels) > 0 {
+				w.WriteByte(',')
+			}
+			fmt.Fprintf(w, "le=\"%s\"", le)
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(v))
+	w.WriteByte('\n')
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string {
+	return helpEscaper.Replace(s)
+}
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
@@ -0,0 +1,90 @@
+package metrics
+
+import (
+	"bytes"
+	"encoding/json"
+	"expvar"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestWritePrometheus checks text exposition format of every metric type
+func TestWritePrometheus(t *testing.T) {
+	reg := NewRegistry()
+	c := reg.NewCounter("requests_total", "Total requests.", "host", "code")
+	g := reg.NewGauge("in_flight", "In flight\nrequests.")
+	h := reg.NewHistogram("latency_seconds", "Latency.", []float64{0.5, 0.1}, "host")
+
+	c.Inc("b.com", "2xx")
+	c.Add(2, "a.com", "5xx")
+	c.Add(-1, "a.com", "5xx")
+	g.Set(3)
+	h.Observe(0.05, `q"\`)
+	h.Observe(0.3, `q"\`)
+	h.Observe(1, `q"\`)
+
+	var buf bytes.Buffer
+	if err := reg.WritePrometheus(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want := `# HELP requests_total Total requests.
+# TYPE requests_total counter
+requests_total{host="a.com",code="5xx"} 2
+requests_total{host="b.com",code="2xx"} 1
+# HELP in_flight In flight\nrequests.
+# TYPE in_flight gauge
+in_flight 3
+# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{host="q\"\\",le="0.1"} 1
+latency_seconds_bucket{host="q\"\\",le="0.5"} 2
+latency_seconds_bucket{host="q\"\\",le="+Inf"} 3
+latency_seconds_sum{host="q\"\\"} 1.35
+latency_seconds_count{host="q\"\\"} 3
+`
+	if got := buf.String(); got != want {
+		t.Errorf("wanted:\n%s\ngot:\n%s", want, got)
+	}
+
+	if n, sum := h.Count(`q"\`); n != 3 || sum != 1.35 {
+		t.Errorf("wanted 3 observations with 1.35 sum got %d and %v", n, sum)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	reg := NewRegistry()
+	reg.NewCounter("requests_total", "Total requests.", "host").Inc("a.com")
+
+	w := httptest.NewRecorder()
+	reg.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := w.Header().Get("Content-Type"); ct != ContentType {
+		t.Errorf("wanted content type %q got %q", ContentType, ct)
+	}
+	if !strings.Contains(w.Body.String(), `requests_total{host="a.com"} 1`+"\n") {
+		t.Errorf("unexpected body:\n%s", w.Body.String())
+	}
+}
+
+func TestPublish(t *testing.T) {
+	reg := NewRegistry()
+	reg.NewGauge("in_flight", "In flight requests.", "host").Set(2, "a.com")
+	reg.NewHistogram("latency_seconds", "Latency.", []float64{0.5}).Observe(0.1)
+	reg.Publish("metrics_test_publish")
+
+	var got map[string][]struct {
+		Labels  map[string]string `json:"labels"`
+		Value   float64           `json:"value"`
+		Buckets map[string]uint64 `json:"buckets"`
+		Count   uint64            `json:"count"`
+	}
+	if err := json.Unmarshal([]byte(expvar.Get("metrics_test_publish").String()), &got); err != nil {
+		t.Fatal(err)
+	}
+	if g := got["in_flight"]; len(g) != 1 || g[0].Labels["host"] != "a.com" || g[0].Value != 2 {
+		t.Errorf("unexpected gauge %+v", g)
+	}
+	if h := got["latency_seconds"]; len(h) != 1 || h[0].Buckets["0.5"] != 1 || h[0].Count != 1 {
+		t.Errorf("unexpected histogram %+v", h)
+	}
+}
@@ -0,0 +1,91 @@
+package httpx
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"collections/httpx/metrics"
+)
+
+func TestClientMetrics(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		if r.URL.Path == "/flaky" && calls.Add(1) == 1 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("hello"))
+	}))
+	defer srv.Close()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed := ln.Addr().String()
+	ln.Close()
+
+	reg := metrics.NewRegistry()
+	m := NewMetrics(reg)
+	c := New(false).SetMetrics(m)
+	ctx := context.Background()
+	consume := func(res *http.Response, err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.Copy(io.Discard, res.Body)
+		res.Body.Close()
+	}
+
+	retry := NewHTTPOptions().RetryHook(func(req *http.Request, res *http.Response, hc *http.Client, err error) (*http.Response, error) {
+		if err != nil || res.StatusCode != http.StatusServiceUnavailable {
+			return res, err
+		}
+		io.Copy(io.Discard, res.Body)
+		res.Body.Close()
+		return hc.Do(req)
+	})
+	consume(c.Get(ctx, srv.URL+"/flaky", retry))
+	consume(c.Post(ctx, srv.URL, strings.NewReader("ping"), nil))
+	if _, err := c.Get(ctx, "http://"+closed, nil); err == nil {
+		t.Fatal("want connect error")
+	}
+
+	m.SetBreakerState(closed, BreakerOpen)
+	m.SetBreakerState(closed, BreakerHalfOpen)
+
+	var buf bytes.Buffer
+	if err := reg.WritePrometheus(&buf); err != nil {
+		t.Fatal(err)
+	}
+	host := strings.TrimPrefix(srv.URL, "http://")
+	for _, want := range []string{
+		fmt.Sprintf(`httpx_requests_total{host=%q,method="GET",status_class="5xx"} 1`, host),
+		fmt.Sprintf(`httpx_requests_total{host=%q,method="GET",status_class="2xx"} 1`, host),
+		fmt.Sprintf(`httpx_requests_total{host=%q,method="POST",status_class="2xx"} 1`, host),
+		fmt.Sprintf(`httpx_request_errors_total{host=%q,method="GET"} 1`, closed),
+		fmt.Sprintf(`httpx_retries_total{host=%q,method="GET"} 1`, host),
+		fmt.Sprintf(`httpx_request_duration_seconds_count{host=%q,method="GET"} 2`, host),
+		fmt.Sprintf(`httpx_request_duration_seconds_bucket{host=%q,method="POST",le="+Inf"} 1`, host),
+		fmt.Sprintf(`httpx_requests_in_flight{host=%q} 0`, host),
+		fmt.Sprintf(`httpx_request_bytes_total{host=%q,method="POST"} 4`, host),
+		fmt.Sprintf(`httpx_response_bytes_total{host=%q,method="GET"} 17`, host),
+		fmt.Sprintf(`httpx_response_bytes_total{host=%q,method="POST"} 5`, host),
+		fmt.Sprintf(`httpx_connections_total{host=%q,reused="false"} 1`, host),
+		fmt.Sprintf(`httpx_connections_total{host=%q,reused="true"} 2`, host),
+		fmt.Sprintf(`httpx_circuit_breaker_state{host=%q} 1`, closed),
+	} {
+		if !strings.Contains(buf.String(), want+"\n") {
+			t.Errorf("missing %s in:\n%s", want, buf.String())
+		}
+	}
+}
@@ -11,9 +11,15 @@ type (
 type HTTPOptions struct {
 	headers      map[string]string
 	queries      map[string]string
-	responseHook ResponseHook
-	requestHook  RequestHook
-	retryHook    RetryHook
+	responseHook ResponseHookV2
+	requestHook  RequestHookV2
+	retryHook    RetryHookV2
+	timings      *Timings
+	upload       *Transfer
+	download     *Transfer
+	redirects    *RedirectHistory
+	compress     *compression
+	maxBodySize  int64
 }
 
 func NewHTTPOptions() *HTTPOptions {
@@ -24,6 +30,9 @@ func NewHTTPOptions() *HTTPOptions {
 }
 
 func (ho *HTTPOptions) Header(k, v string) *HTTPOptions {
+	if ho.headers == nil {
+		ho.headers = make(map[string]string)
+	}
 	ho.headers[k] = v
 	return ho
 }
@@ -34,6 +43,9 @@ func (ho *HTTPOptions) Headers(hdrs map[string]string) *HTTPOptions {
 }
 
 func (ho *HTTPOptions) Query(k, v string) *HTTPOptions {
+	if ho.queries == nil {
+		ho.queries = make(map[string]string)
+	}
 	ho.queries[k] = v
 	return ho
 }
@@ -44,16 +56,22 @@ func (ho *HTTPOptions) Queries(queries map[string]string) *HTTPOptions {
 }
 
 func (ho *HTTPOptions) RequestHook(hook RequestHook) *HTTPOptions {
-	ho.requestHook = hook
+	ho.requestHook = hook.V2()
 	return ho
 }
 
 func (ho *HTTPOptions) ResponseHook(hook ResponseHook) *HTTPOptions {
-	ho.responseHook = hook
+	ho.responseHook = hook.V2()
 	return ho
 }
 
 func (ho *HTTPOptions) RetryHook(hook RetryHook) *HTTPOptions {
-	ho.retryHook = hook
+	ho.retryHook = hook.V2()
+	return ho
+}
+
+// Timings records the timing breakdown of every round trip performed by Exec into t.
+func (ho *HTTPOptions) Timings(t *Timings) *HTTPOptions {
+	ho.timings = t
 	return ho
 }
@@ -0,0 +1,3 @@
+// Package paginate contains generic iterator over items of paginated REST APIs supporting
+// Link header, cursor, offset/limit and page number pagination
+package paginate
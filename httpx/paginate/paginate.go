@@ -0,0 +1,224 @@
+package paginate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"collections/httpx"
+)
+
+const defaultMaxPages = 1000
+
+// ErrMaxPages is yielded when there are more pages after [Paginator.MaxPages] were fetched.
+var ErrMaxPages = errors.New("paginate: maximum pages reached")
+
+// StatusError is yielded when page request responds with non 2xx status.
+type StatusError struct {
+	StatusCode int
+	URL        string
+}
+
+func (e StatusError) Error() string {
+	return fmt.Sprintf("paginate: unexpected response status=%d, url=%s", e.StatusCode, e.URL)
+}
+
+// PageInfo describes the fetched page, it's passed to [Strategy] for building next page request.
+type PageInfo struct {
+	// Number is 1 based page number
+	Number int
+	URL    *url.URL
+	// Response body is already consumed
+	Response *http.Response
+	// Count is number of items in page
+	Count int
+	// Cursor is cursor or token of next page returned by decoder
+	Cursor string
+}
+
+// Page is the fetched page along with its items.
+type Page[T any] struct {
+	PageInfo
+	Items []T
+}
+
+// Decoder reads items of page and optional cursor of next page from the response.
+type Decoder[T any] func(res *http.Response) (items []T, cursor string, err error)
+
+// Paginator fetches pages of API one at a time. Every page is requested through
+// [httpx.Client.Get] so bulkhead, metrics and hooks of returned options such as
+// retries apply to every page.
+type Paginator[T any] struct {
+	Client *httpx.Client
+	URL    string
+	// Options returns options of page request, it's called for every page so
+	// stateful hooks are not shared between pages
+	Options func() *httpx.HTTPOptions
+	// Strategy builds url of next page, default is [LinkHeader]
+	Strategy Strategy
+	// Decode reads the page, default decodes JSON array body
+	Decode Decoder[T]
+	// MaxPages is the safety limit of fetched pages, default is 1000
+	MaxPages int
+}
+
+// Items returns iterator over items of all pages. Iteration ends after error is yielded.
+func (p *Paginator[T]) Items(ctx context.Context) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for page, err := range p.Pages(ctx) {
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+			for _, item := range page.Items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Pages returns iterator over pages. Iteration ends after error is yielded.
+func (p *Paginator[T]) Pages(ctx context.Context) iter.Seq2[*Page[T], error] {
+	return func(yield func(*Page[T], error) bool) {
+		strategy := p.Strategy
+		if strategy == nil {
+			strategy = LinkHeader{}
+		}
+		decode := p.Decode
+		if decode == nil {
+			decode = DecodeJSON[T]("", "")
+		}
+		maxPages := p.MaxPages
+		if maxPages <= 0 {
+			maxPages = defaultMaxPages
+		}
+
+		u, err := url.Parse(p.URL)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		strategy.First(u)
+
+		for n := 1; ; n++ {
+			if n > maxPages {
+				yield(nil, ErrMaxPages)
+				return
+			}
+			page, err := p.fetch(ctx, n, u, decode)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if !yield(page, nil) {
+				return
+			}
+
+			next, ok := strategy.Next(page.PageInfo)
+			// same url would loop forever
+			if !ok || next.String() == u.String() {
+				return
+			}
+			u = next
+		}
+	}
+}
+
+func (p *Paginator[T]) fetch(ctx context.Context, n int, u *url.URL, decode Decoder[T]) (*Page[T], error) {
+	ho := httpx.NewHTTPOptions()
+	if p.Options != nil {
+		ho = p.Options()
+	}
+	res, err := p.Client.Get(ctx, u.String(), ho)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return nil, StatusError{StatusCode: res.StatusCode, URL: u.String()}
+	}
+
+	items, cursor, err := decode(res)
+	if err != nil {
+		return nil, fmt.Errorf("paginate: failed to decode page=%d: %w", n, err)
+	}
+	return &Page[T]{
+		PageInfo: PageInfo{Number: n, URL: u, Response: res, Count: len(items), Cursor: cursor},
+		Items:    items,
+	}, nil
+}
+
+// DecodeJSON returns decoder of JSON body. items is the dot separated path of items array
+// e.g. "data.items", empty path means body itself is the array. cursor is the path of next
+// page cursor, it can be string or number, missing or null cursor is returned as empty.
+func DecodeJSON[T any](items, cursor string) Decoder[T] {
+	return func(res *http.Response) ([]T, string, error) {
+		b, err := io.ReadAll(res.Body)
+		if err != nil {
+			return nil, "", err
+		}
+
+		var out []T
+		raw, err := lookup(b, items)
+		if err != nil {
+			return nil, "", err
+		}
+		if raw != nil {
+			if err := json.Unmarshal(raw, &out); err != nil {
+				return nil, "", err
+			}
+		}
+
+		if cursor == "" {
+			return out, "", nil
+		}
+		raw, err = lookup(b, cursor)
+		if err != nil || raw == nil {
+			return out, "", err
+		}
+		var s string
+		if err := json.Unmarshal(raw, &s); err == nil {
+			return out, s, nil
+		}
+		var num json.Number
+		if err := json.Unmarshal(raw, &num); err != nil {
+			return nil, "", fmt.Errorf("cursor %s is neither string nor number", cursor)
+		}
+		return out, num.String(), nil
+	}
+}
+
+// lookup returns raw value at dot separated path, nil is returned for missing or null value
+func lookup(b []byte, path string) (json.RawMessage, error) {
+	raw := json.RawMessage(b)
+	if path == "" {
+		return raw, nil
+	}
+	for key := range strings.SplitSeq(path, ".") {
+		if bytes.Equal(bytes.TrimSpace(raw), []byte("null")) {
+			return nil, nil
+		}
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &obj); err != nil {
+			return nil, err
+		}
+		var ok bool
+		if raw, ok = obj[key]; !ok {
+			return nil, nil
+		}
+	}
+	if bytes.Equal(bytes.TrimSpace(raw), []byte("null")) {
+		return nil, nil
+	}
+	return raw, nil
+}
@@ -0,0 +1,107 @@
+package paginate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"testing"
+
+	"collections/httpx"
+)
+
+// items serves 0..9 sliced by offset and limit queries
+func items(r *http.Request) []int {
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	out := []int{}
+	for i := offset; i < min(offset+limit, 10); i++ {
+		out = append(out, i)
+	}
+	return out
+}
+
+func collect(t *testing.T, p *Paginator[int]) []int {
+	t.Helper()
+	var got []int
+	for v, err := range p.Items(context.Background()) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, v)
+	}
+	return got
+}
+
+func TestStrategies(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/link", func(w http.ResponseWriter, r *http.Request) {
+		page := items(r)
+		if offset, _ := strconv.Atoi(r.URL.Query().Get("offset")); offset+3 < 10 {
+			w.Header().Add("Link", `<https://example.com/prev>; rel="prev"`)
+			w.Header().Add("Link", fmt.Sprintf(`<?offset=%d&limit=3>; title="a, b"; rel="last next"`, offset+3))
+		}
+		json.NewEncoder(w).Encode(page)
+	})
+	mux.HandleFunc("/cursor", func(w http.ResponseWriter, r *http.Request) {
+		r.URL.RawQuery += "&limit=4"
+		if c := r.URL.Query().Get("cursor"); c != "" {
+			r.URL.RawQuery += "&offset=" + c
+		}
+		page := items(r)
+		next := any(nil)
+		if len(page) == 4 {
+			next = page[3] + 1
+		}
+		json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"items": page}, "meta": map[string]any{"next": next}})
+	})
+	mux.HandleFunc("/offset", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(items(r))
+	})
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		r.URL.RawQuery = fmt.Sprintf("offset=%d&limit=5", (page-1)*5)
+		json.NewEncoder(w).Encode(items(r))
+	})
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+
+	want := []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}
+	c := httpx.New(false)
+	tests := map[string]*Paginator[int]{
+		"link": {URL: ts.URL + "/link?offset=0&limit=3"},
+		"cursor": {
+			URL:      ts.URL + "/cursor",
+			Strategy: Cursor{Param: "cursor"},
+			Decode:   DecodeJSON[int]("data.items", "meta.next"),
+		},
+		"offset": {URL: ts.URL + "/offset", Strategy: OffsetLimit{OffsetParam: "offset", LimitParam: "limit", Limit: 3}},
+		"page":   {URL: ts.URL + "/page", Strategy: PageNumber{Param: "page"}},
+	}
+	for name, p := range tests {
+		t.Run(name, func(t *testing.T) {
+			p.Client = c
+			if got := collect(t, p); !slices.Equal(got, want) {
+				t.Errorf("wanted %v got %v", want, got)
+			}
+		})
+	}
+
+	p := &Paginator[int]{Client: c, URL: ts.URL + "/page", Strategy: PageNumber{Param: "page"}, MaxPages: 2}
+	var got []int
+	var err error
+	for v, e := range p.Items(context.Background()) {
+		if e != nil {
+			err = e
+			break
+		}
+		got = append(got, v)
+	}
+	if !errors.Is(err, ErrMaxPages) || len(got) != 10 {
+		t.Errorf("wanted ErrMaxPages after 10 items got %v after %d", err, len(got))
+	}
+}
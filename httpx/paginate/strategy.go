@@ -0,0 +1,171 @@
+package paginate
+
+import (
+	"iter"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Strategy builds the request url of pages.
+type Strategy interface {
+	// First sets up the url of first page
+	First(u *url.URL)
+	// Next returns url of next page, false is returned when there are no more pages
+	Next(p PageInfo) (*url.URL, bool)
+}
+
+// LinkHeader follows the RFC 8288 Link header with rel="next", relative links are
+// resolved against the page url.
+type LinkHeader struct{}
+
+func (LinkHeader) First(*url.URL) {}
+
+func (LinkHeader) Next(p PageInfo) (*url.URL, bool) {
+	for _, v := range p.Response.Header.Values("Link") {
+		for target, params := range parseLinks(v) {
+			for rel := range strings.FieldsSeq(params["rel"]) {
+				if strings.EqualFold(rel, "next") {
+					next, err := p.URL.Parse(target)
+					return next, err == nil
+				}
+			}
+		}
+	}
+	return nil, false
+}
+
+// parseLinks yields target and lower cased parameters of every link value
+func parseLinks(v string) iter.Seq2[string, map[string]string] {
+	return func(yield func(string, map[string]string) bool) {
+		for v != "" {
+			start := strings.IndexByte(v, '<')
+			if start < 0 {
+				return
+			}
+			end := strings.IndexByte(v[start:], '>')
+			if end < 0 {
+				return
+			}
+			target := v[start+1 : start+end]
+			v = v[start+end+1:]
+
+			params := map[string]string{}
+			for {
+				v = strings.TrimLeft(v, " \t")
+				if v == "" || v[0] != ';' {
+					break
+				}
+				v = strings.TrimLeft(v[1:], " \t")
+				i := strings.IndexAny(v, "=;,")
+				if i < 0 {
+					params[strings.ToLower(strings.TrimSpace(v))] = ""
+					v = ""
+					break
+				}
+				key := strings.ToLower(strings.TrimSpace(v[:i]))
+				if v[i] != '=' {
+					params[key] = ""
+					v = v[i:]
+					continue
+				}
+				v = strings.TrimLeft(v[i+1:], " \t")
+				var value string
+				if strings.HasPrefix(v, `"`) {
+					q := strings.IndexByte(v[1:], '"')
+					if q < 0 {
+						value, v = v[1:], ""
+					} else {
+						value, v = v[1:q+1], v[q+2:]
+					}
+				} else {
+					j := strings.IndexAny(v, ";,")
+					if j < 0 {
+						j = len(v)
+					}
+					value, v = strings.TrimSpace(v[:j]), v[j:]
+				}
+				params[key] = value
+			}
+			if !yield(target, params) {
+				return
+			}
+			v = strings.TrimLeft(v, " \t,")
+		}
+	}
+}
+
+// Cursor sends the cursor or token returned by decoder as Param query of next page,
+// pagination ends when cursor is empty.
+type Cursor struct {
+	Param string
+}
+
+func (Cursor) First(*url.URL) {}
+
+func (c Cursor) Next(p PageInfo) (*url.URL, bool) {
+	if p.Cursor == "" {
+		return nil, false
+	}
+	return withQuery(p.URL, c.Param, p.Cursor), true
+}
+
+// OffsetLimit sends OffsetParam and LimitParam queries advancing offset by number of
+// items in page, pagination ends with page shorter than Limit.
+type OffsetLimit struct {
+	OffsetParam string
+	LimitParam  string
+	Limit       int
+	// Offset is the offset of first page
+	Offset int
+}
+
+func (o OffsetLimit) First(u *url.URL) {
+	*u = *withQuery(withQuery(u, o.OffsetParam, strconv.Itoa(o.Offset)), o.LimitParam, strconv.Itoa(o.Limit))
+}
+
+func (o OffsetLimit) Next(p PageInfo) (*url.URL, bool) {
+	if p.Count == 0 || p.Count < o.Limit {
+		return nil, false
+	}
+	offset, _ := strconv.Atoi(p.URL.Query().Get(o.OffsetParam))
+	return withQuery(p.URL, o.OffsetParam, strconv.Itoa(offset+p.Count)), true
+}
+
+// PageNumber sends Param query with page number starting from Start, default is 1. If Size
+// is set it's sent as SizeParam query and pagination ends with page shorter than Size,
+// otherwise it ends with empty page.
+type PageNumber struct {
+	Param     string
+	Start     int
+	SizeParam string
+	Size      int
+}
+
+func (pn PageNumber) First(u *url.URL) {
+	start := pn.Start
+	if start == 0 {
+		start = 1
+	}
+	*u = *withQuery(u, pn.Param, strconv.Itoa(start))
+	if pn.Size > 0 && pn.SizeParam != "" {
+		*u = *withQuery(u, pn.SizeParam, strconv.Itoa(pn.Size))
+	}
+}
+
+func (pn PageNumber) Next(p PageInfo) (*url.URL, bool) {
+	if p.Count == 0 || pn.Size > 0 && p.Count < pn.Size {
+		return nil, false
+	}
+	page, _ := strconv.Atoi(p.URL.Query().Get(pn.Param))
+	return withQuery(p.URL, pn.Param, strconv.Itoa(page+1)), true
+}
+
+// withQuery returns copy of u with query k set to v
+func withQuery(u *url.URL, k, v string) *url.URL {
+	next := *u
+	q := next.Query()
+	q.Set(k, v)
+	next.RawQuery = q.Encode()
+	return &next
+}
@@ -0,0 +1,240 @@
+package httpx
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultProgressInterval = 250 * time.Millisecond
+	// maxLimitedChunk is the maximum bytes transferred at once when bandwidth is limited
+	maxLimitedChunk = 32 * 1024
+)
+
+// Progress of body transfer.
+type Progress struct {
+	Done int64
+	// Total is -1 when size of body is unknown
+	Total int64
+	// Rate is average bytes per second since the transfer started
+	Rate float64
+	// ETA is zero when total is unknown
+	ETA time.Duration
+}
+
+// ProgressFunc receives the progress of body transfer.
+type ProgressFunc func(Progress)
+
+// Transfer configures progress reporting and bandwidth cap of body.
+type Transfer struct {
+	Progress ProgressFunc
+	// Interval is the minimum time between progress calls, default is 250ms.
+	// Progress is always reported once transfer ends.
+	Interval time.Duration
+	// Limit caps bytes per second of the body, zero is unlimited
+	Limit int64
+	// Limiter is shared limiter applied along with Limit
+	Limiter *Limiter
+}
+
+// Upload reports progress and limits bandwidth of request body. Request replayed by
+// redirects or retries through GetBody reports the progress from start again and
+// shares the bandwidth cap.
+func (ho *HTTPOptions) Upload(t Transfer) *HTTPOptions {
+	ho.upload = &t
+	return ho
+}
+
+// Download reports progress and limits bandwidth of response body returned by Exec.
+func (ho *HTTPOptions) Download(t Transfer) *HTTPOptions {
+	ho.download = &t
+	return ho
+}
+
+// meter tracks transferred bytes, reports progress and waits on limiters.
+type meter struct {
+	ctx      context.Context
+	fn       ProgressFunc
+	interval time.Duration
+	limiters []*Limiter
+	total    int64
+	done     int64
+	start    time.Time
+	last     time.Time
+	finished bool
+}
+
+func newMeter(ctx context.Context, total int64, t Transfer, limiters ...*Limiter) *meter {
+	m := &meter{ctx: ctx, fn: t.Progress, interval: t.Interval, total: total, start: time.Now()}
+	if m.interval <= 0 {
+		m.interval = defaultProgressInterval
+	}
+	if t.Limit > 0 {
+		m.limiters = append(m.limiters, NewLimiter(t.Limit))
+	}
+	if t.Limiter.limited() {
+		m.limiters = append(m.limiters, t.Limiter)
+	}
+	for _, l := range limiters {
+		if l.limited() {
+			m.limiters = append(m.limiters, l)
+		}
+	}
+	return m
+}
+
+// chunk returns size of next transfer
+func (m *meter) chunk(n int) int {
+	if len(m.limiters) > 0 {
+		return min(n, maxLimitedChunk)
+	}
+	return n
+}
+
+// add accounts n bytes and waits till they fit within bandwidth limits
+func (m *meter) add(n int) error {
+	if n > 0 {
+		m.done += int64(n)
+		m.report(false)
+	}
+	for _, l := range m.limiters {
+		if err := l.WaitN(m.ctx, n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *meter) report(final bool) {
+	if m.fn == nil || m.finished {
+		return
+	}
+	now := time.Now()
+	if !final && now.Sub(m.last) < m.interval {
+		return
+	}
+	m.last = now
+	m.finished = final
+
+	p := Progress{Done: m.done, Total: m.total}
+	if elapsed := now.Sub(m.start).Seconds(); elapsed > 0 {
+		p.Rate = float64(m.done) / elapsed
+	}
+	if m.total > 0 && p.Rate > 0 && m.done < m.total {
+		p.ETA = time.Duration(float64(m.total-m.done) / p.Rate * float64(time.Second))
+	}
+	m.fn(p)
+}
+
+type transferReader struct {
+	r io.Reader
+	m *meter
+}
+
+// NewTransferReader wraps r so reads report progress and respect the bandwidth limit of t,
+// total is the expected size or -1 if unknown. Reads fail with context error once ctx is done
+// while waiting on limiter. Close closes r if it's [io.Closer]. Final progress is reported
+// once r returns EOF or fails.
+func NewTransferReader(ctx context.Context, r io.Reader, total int64, t Transfer) io.ReadCloser {
+	return &transferReader{r: r, m: newMeter(ctx, total, t)}
+}
+
+func (tr *transferReader) Read(p []byte) (int, error) {
+	n, err := tr.r.Read(p[:tr.m.chunk(len(p))])
+	if werr := tr.m.add(n); werr != nil && err == nil {
+		err = werr
+	}
+	if err != nil {
+		tr.m.report(true)
+	}
+	return n, err
+}
+
+// Close doesn't report progress as transport may close request body concurrently with Read
+func (tr *transferReader) Close() error {
+	if c, ok := tr.r.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+type transferWriter struct {
+	w io.Writer
+	m *meter
+}
+
+// NewTransferWriter wraps w so writes report progress and respect the bandwidth limit of t,
+// total is the expected size or -1 if unknown. Final progress is reported once total bytes
+// are written.
+func NewTransferWriter(ctx context.Context, w io.Writer, total int64, t Transfer) io.Writer {
+	return &transferWriter{w: w, m: newMeter(ctx, total, t)}
+}
+
+func (tw *transferWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		n, err := tw.w.Write(p[:tw.m.chunk(len(p))])
+		written += n
+		p = p[n:]
+		if werr := tw.m.add(n); werr != nil && err == nil {
+			err = werr
+		}
+		if err != nil {
+			tw.m.report(true)
+			return written, err
+		}
+	}
+	if tw.m.total >= 0 && tw.m.done >= tw.m.total {
+		tw.m.report(true)
+	}
+	return written, nil
+}
+
+// wrapUpload wraps request body and GetBody with the upload transfer,
+// per request limiter is shared between the replays.
+func (c *Client) wrapUpload(req *http.Request, t *Transfer) {
+	if req.Body == nil || req.Body == http.NoBody || (t == nil && !c.uploadLimiter.limited()) {
+		return
+	}
+	tr := Transfer{}
+	if t != nil {
+		tr = *t
+	}
+	limiters := []*Limiter{c.uploadLimiter, nil}
+	if tr.Limit > 0 {
+		limiters[1] = NewLimiter(tr.Limit)
+		tr.Limit = 0
+	}
+	total := req.ContentLength
+	if total == 0 {
+		total = -1
+	}
+	wrap := func(rc io.ReadCloser) io.ReadCloser {
+		return &transferReader{r: rc, m: newMeter(req.Context(), total, tr, limiters...)}
+	}
+
+	req.Body = wrap(req.Body)
+	if getBody := req.GetBody; getBody != nil {
+		req.GetBody = func() (io.ReadCloser, error) {
+			rc, err := getBody()
+			if err != nil {
+				return nil, err
+			}
+			return wrap(rc), nil
+		}
+	}
+}
+
+// wrapDownload wraps response body with the download transfer
+func (c *Client) wrapDownload(ctx context.Context, res *http.Response, t *Transfer) {
+	if res == nil || res.Body == nil || (t == nil && !c.downloadLimiter.limited()) {
+		return
+	}
+	tr := Transfer{}
+	if t != nil {
+		tr = *t
+	}
+	res.Body = &transferReader{r: res.Body, m: newMeter(ctx, res.ContentLength, tr, c.downloadLimiter)}
+}
@@ -0,0 +1,82 @@
+package httpx
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUploadProgressReplay(t *testing.T) {
+	body := strings.Repeat("a", 64*1024)
+	var sent []int
+	c := New(false).SetTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		b, _ := io.ReadAll(req.Body)
+		sent = append(sent, len(b))
+		if len(sent) == 1 {
+			return &http.Response{StatusCode: http.StatusTemporaryRedirect, Header: http.Header{
+				"Location": {"/again"},
+			}, Body: http.NoBody}, nil
+		}
+		return okResponse(), nil
+	}))
+
+	var last Progress
+	var calls int
+	ho := NewHTTPOptions().Upload(Transfer{
+		Progress: func(p Progress) {
+			calls++
+			last = p
+		},
+		Limit: 512 * 1024,
+	})
+	start := time.Now()
+	res, err := c.Put(context.Background(), "http://example.com", strings.NewReader(body), ho)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	if len(sent) != 2 || sent[0] != len(body) || sent[1] != len(body) {
+		t.Fatalf("wanted body sent twice got %v", sent)
+	}
+	if last.Done != int64(len(body)) || last.Total != int64(len(body)) || last.Rate <= 0 {
+		t.Errorf("unexpected final progress %+v", last)
+	}
+	if calls < 2 {
+		t.Errorf("wanted progress for every replay got %d calls", calls)
+	}
+	// 128KB at 512KB/s with 51KB burst takes at least 150ms
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("bandwidth is not limited, took %s", elapsed)
+	}
+}
+
+func TestDownloadLimit(t *testing.T) {
+	body := strings.Repeat("a", 32*1024)
+	c := New(false).SetBandwidth(0, 128*1024).SetTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode:    http.StatusOK,
+			ContentLength: int64(len(body)),
+			Body:          io.NopCloser(strings.NewReader(body)),
+		}, nil
+	}))
+	var last Progress
+	ho := NewHTTPOptions().Download(Transfer{Progress: func(p Progress) { last = p }})
+
+	start := time.Now()
+	res, err := c.Get(context.Background(), "http://example.com", ho)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := io.ReadAll(res.Body)
+	res.Body.Close()
+	if len(b) != len(body) || last.Done != int64(len(body)) {
+		t.Errorf("unexpected body length %d, progress %+v", len(b), last)
+	}
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("bandwidth is not limited, took %s", elapsed)
+	}
+}
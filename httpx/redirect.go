@@ -0,0 +1,136 @@
+package httpx
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const defaultMaxRedirects = 10
+
+// RedirectPolicy controls which redirects are followed by [Client].
+type RedirectPolicy struct {
+	// MaxHops is maximum number of followed redirects, default is 10
+	MaxHops int
+	// SameHost allows redirects only to the host of original request
+	SameHost bool
+	// SameScheme allows redirects only to the scheme of original request
+	SameScheme bool
+	// NoDowngrade refuses redirects from https to http
+	NoDowngrade bool
+	// KeepAuthorization keeps Authorization header when redirected to other host. By default
+	// it's removed for any other host, including the subdomains net/http keeps it for.
+	KeepAuthorization bool
+}
+
+// RedirectError is returned when redirect is refused by [RedirectPolicy].
+// It's wrapped in [net/url.Error] returned by Exec.
+type RedirectError struct {
+	From   string
+	To     string
+	Reason string
+}
+
+func (e RedirectError) Error() string {
+	return fmt.Sprintf("redirect refused from=%s, to=%s, reason=%s", e.From, e.To, e.Reason)
+}
+
+// SetRedirectPolicy follows redirects according to the policy, it replaces [Client.DisableRedirect].
+func (c *Client) SetRedirectPolicy(p RedirectPolicy) *Client {
+	if p.MaxHops <= 0 {
+		p.MaxHops = defaultMaxRedirects
+	}
+	c.client.CheckRedirect = p.check
+	return c
+}
+
+func (p RedirectPolicy) check(req *http.Request, via []*http.Request) error {
+	first, prev := via[0], via[len(via)-1]
+	refuse := func(reason string) error {
+		return RedirectError{From: prev.URL.String(), To: req.URL.String(), Reason: reason}
+	}
+	switch {
+	case len(via) > p.MaxHops:
+		return refuse(fmt.Sprintf("stopped after %d redirects", p.MaxHops))
+	case p.SameHost && req.URL.Host != first.URL.Host:
+		return refuse("different host")
+	case p.SameScheme && req.URL.Scheme != first.URL.Scheme:
+		return refuse("different scheme")
+	case p.NoDowngrade && prev.URL.Scheme == "https" && req.URL.Scheme == "http":
+		return refuse("https downgrade")
+	}
+
+	auth := first.Header.Values("Authorization")
+	switch {
+	case req.URL.Host != first.URL.Host && !p.KeepAuthorization:
+		req.Header.Del("Authorization")
+	case len(auth) > 0:
+		req.Header["Authorization"] = auth
+	}
+	return nil
+}
+
+// Hop is single round trip of redirect chain.
+type Hop struct {
+	// Attempt is the attempt number of round trip, redirects share the attempt number
+	Attempt    int
+	Method     string
+	URL        string
+	StatusCode int
+	// Location is the redirect target sent by server
+	Location string
+	Start    time.Time
+	// Duration is the time till response headers
+	Duration time.Duration
+	Err      error
+}
+
+// RedirectHistory records every round trip performed by single Exec call.
+type RedirectHistory struct {
+	mu   sync.Mutex
+	hops []Hop
+}
+
+// Hops returns all the round trips in order they were performed, including the
+// ones of failed attempts.
+func (h *RedirectHistory) Hops() []Hop {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]Hop(nil), h.hops...)
+}
+
+// Chain returns round trips of last attempt, the last hop produced the final response.
+func (h *RedirectHistory) Chain() []Hop {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	i := len(h.hops)
+	for i > 0 && h.hops[i-1].Attempt == h.hops[len(h.hops)-1].Attempt {
+		i--
+	}
+	return append([]Hop(nil), h.hops[i:]...)
+}
+
+func (h *RedirectHistory) record(req *http.Request, attempt int, start time.Time, res *http.Response, err error) {
+	hop := Hop{
+		Attempt:  attempt,
+		Method:   req.Method,
+		URL:      req.URL.String(),
+		Start:    start,
+		Duration: time.Since(start),
+		Err:      err,
+	}
+	if res != nil {
+		hop.StatusCode = res.StatusCode
+		hop.Location = res.Header.Get("Location")
+	}
+	h.mu.Lock()
+	h.hops = append(h.hops, hop)
+	h.mu.Unlock()
+}
+
+// RedirectHistory records the redirect chain of Exec into h.
+func (ho *HTTPOptions) RedirectHistory(h *RedirectHistory) *HTTPOptions {
+	ho.redirects = h
+	return ho
+}
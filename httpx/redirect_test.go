@@ -0,0 +1,75 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRedirectPolicy(t *testing.T) {
+	var gotAuth []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/a", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/b", http.StatusFound)
+	})
+	mux.HandleFunc("/b", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/c", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/c", func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = append(gotAuth, r.Header.Get("Authorization"))
+	})
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+
+	var h RedirectHistory
+	c := New(false).SetRedirectPolicy(RedirectPolicy{SameHost: true})
+	ho := NewHTTPOptions().Header("Authorization", "Bearer x").RedirectHistory(&h)
+	res, err := c.Get(context.Background(), ts.URL+"/a", ho)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	chain := h.Chain()
+	wantStatus := []int{http.StatusFound, http.StatusMovedPermanently, http.StatusOK}
+	if len(chain) != len(wantStatus) {
+		t.Fatalf("wanted %d hops got %+v", len(wantStatus), chain)
+	}
+	for i, hop := range chain {
+		if hop.StatusCode != wantStatus[i] || hop.Attempt != 1 {
+			t.Errorf("unexpected hop %d %+v", i, hop)
+		}
+	}
+	if chain[0].Location != "/b" || chain[2].URL != ts.URL+"/c" {
+		t.Errorf("unexpected chain %+v", chain)
+	}
+	if len(gotAuth) != 1 || gotAuth[0] != "Bearer x" {
+		t.Errorf("authorization is not kept on same host %v", gotAuth)
+	}
+
+	c.SetRedirectPolicy(RedirectPolicy{MaxHops: 1})
+	_, err = c.Get(context.Background(), ts.URL+"/a", nil)
+	var re RedirectError
+	if !errors.As(err, &re) || re.To != ts.URL+"/c" {
+		t.Errorf("wanted RedirectError for second hop got %v", err)
+	}
+
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, ts.URL+"/c", http.StatusFound)
+	}))
+	t.Cleanup(other.Close)
+	gotAuth = nil
+	for _, keep := range []bool{false, true} {
+		c.SetRedirectPolicy(RedirectPolicy{KeepAuthorization: keep})
+		res, err := c.Get(context.Background(), other.URL, NewHTTPOptions().Header("Authorization", "Bearer x"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+	}
+	if len(gotAuth) != 2 || gotAuth[0] != "" || gotAuth[1] != "Bearer x" {
+		t.Errorf("wanted authorization stripped then kept got %q", gotAuth)
+	}
+}
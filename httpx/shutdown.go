@@ -0,0 +1,123 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// ErrClientClosed is returned by requests started after [Client.Shutdown] and wraps errors of
+// requests cancelled by it.
+var ErrClientClosed = errors.New("client closed")
+
+// lifecycle tracks requests in flight so client can be shut down gracefully
+type lifecycle struct {
+	mu       sync.Mutex
+	closed   bool
+	inflight int64
+	// idle is closed once client is closed and no request is in flight
+	idle     chan struct{}
+	idleOnce sync.Once
+	// ctx of every request, it's cancelled when shutdown deadline passes
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+}
+
+func newLifecycle() *lifecycle {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	return &lifecycle{idle: make(chan struct{}), ctx: ctx, cancel: cancel}
+}
+
+// begin registers request, returned context is cancelled on shutdown deadline and release
+// must be called once request and its body are done
+func (l *lifecycle) begin(ctx context.Context) (context.Context, func(), error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return nil, nil, ErrClientClosed
+	}
+	l.inflight++
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	stop := context.AfterFunc(l.ctx, func() { cancel(context.Cause(l.ctx)) })
+	var once sync.Once
+	release := func() {
+		once.Do(func() {
+			stop()
+			cancel(nil)
+			l.mu.Lock()
+			l.inflight--
+			l.checkIdle()
+			l.mu.Unlock()
+		})
+	}
+	return ctx, release, nil
+}
+
+// checkIdle must be called with mu held
+func (l *lifecycle) checkIdle() {
+	if l.closed && l.inflight == 0 {
+		l.idleOnce.Do(func() { close(l.idle) })
+	}
+}
+
+// InFlight returns number of requests in flight, request is in flight until its response
+// body is read till EOF or closed.
+func (c *Client) InFlight() int64 {
+	c.life.mu.Lock()
+	defer c.life.mu.Unlock()
+	return c.life.inflight
+}
+
+// Shutdown gracefully shuts down the client. New requests are rejected with [ErrClientClosed]
+// while in flight requests including their retries are waited for. Once ctx is done the
+// remaining requests are cancelled and cause of ctx is returned. Idle connections are closed
+// in either case.
+func (c *Client) Shutdown(ctx context.Context) error {
+	c.life.mu.Lock()
+	c.life.closed = true
+	c.life.checkIdle()
+	c.life.mu.Unlock()
+
+	var err error
+	select {
+	case <-c.life.idle:
+	case <-ctx.Done():
+		err = context.Cause(ctx)
+		c.life.cancel(ErrClientClosed)
+	}
+	c.client.CloseIdleConnections()
+	return err
+}
+
+// Close is [Client.Shutdown], done ctx cancels requests in flight right away.
+func (c *Client) Close(ctx context.Context) error {
+	return c.Shutdown(ctx)
+}
+
+// track runs exec as request in flight until its response body is done
+func (c *Client) track(
+	ctx context.Context,
+	exec func(context.Context) (*http.Response, error),
+) (*http.Response, error) {
+	ctx, release, err := c.life.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res, err := exec(ctx)
+	if err != nil {
+		if errors.Is(context.Cause(ctx), ErrClientClosed) {
+			err = fmt.Errorf("%w: %w", ErrClientClosed, err)
+		}
+		release()
+		return res, err
+	}
+	if res == nil || res.Body == nil {
+		release()
+		return res, nil
+	}
+	res.Body = &doneBody{ReadCloser: res.Body, done: release}
+	return res, nil
+}
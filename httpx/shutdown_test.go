@@ -0,0 +1,86 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestShutdown(t *testing.T) {
+	release := make(chan struct{})
+	c := New(false).SetTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		select {
+		case <-release:
+			return okResponse(), nil
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}))
+
+	// response of first request is in flight until its body is closed
+	close(release)
+	res, err := c.Get(context.Background(), "http://example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := c.InFlight(); n != 1 {
+		t.Fatalf("want 1 request in flight, got %d", n)
+	}
+	done := make(chan error, 1)
+	go func() { done <- c.Shutdown(context.Background()) }()
+
+	// wait till shutdown starts rejecting requests
+	for {
+		res, err := c.Get(context.Background(), "http://example.com", nil)
+		if errors.Is(err, ErrClientClosed) {
+			break
+		}
+		res.Body.Close()
+		time.Sleep(time.Millisecond)
+	}
+	select {
+	case err := <-done:
+		t.Fatalf("shutdown returned before body was closed err=%v", err)
+	default:
+	}
+	io.ReadAll(res.Body)
+	if err := <-done; err != nil || c.InFlight() != 0 {
+		t.Fatalf("got err=%v, in flight=%d", err, c.InFlight())
+	}
+}
+
+func TestShutdownDeadline(t *testing.T) {
+	started := make(chan struct{})
+	c := New(false).SetTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		close(started)
+		<-req.Context().Done()
+		return nil, req.Context().Err()
+	}))
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := c.Get(context.Background(), "http://example.com", nil)
+		errCh <- err
+	}()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := c.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("want deadline exceeded, got %v", err)
+	}
+	if err := <-errCh; !errors.Is(err, ErrClientClosed) {
+		t.Fatalf("want ErrClientClosed, got %v", err)
+	}
+
+	// closing again waits for nothing
+	if err := c.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Get(context.Background(), "http://example.com", nil); !errors.Is(err, ErrClientClosed) {
+		t.Fatalf("want ErrClientClosed after close, got %v", err)
+	}
+}
@@ -0,0 +1,58 @@
+package signer
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+)
+
+var (
+	// ErrMissingSignature is returned by verifiers when request is not signed.
+	ErrMissingSignature = errors.New("signer: missing signature")
+	// ErrSignatureMismatch is returned by verifiers when signature doesn't match.
+	ErrSignatureMismatch = errors.New("signer: signature mismatch")
+	// ErrSignatureExpired is returned by verifiers when signing time is outside the allowed skew
+	// or presigned url expired.
+	ErrSignatureExpired = errors.New("signer: signature expired")
+)
+
+// readBody returns request body without consuming it. Body is read through GetBody when
+// available, otherwise it's buffered and replaced so it can still be sent or read by handler.
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	if req.GetBody != nil {
+		rc, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return io.ReadAll(rc)
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	return body, nil
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,3 @@
+// Package signer contains AWS Signature Version 4 and HMAC-SHA256 request signers which can be
+// used as request hooks of httpx client, along with the verifiers for the receiving side
+package signer
@@ -0,0 +1,139 @@
+package signer
+
+import (
+	"crypto/hmac"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultSignatureHeader = "X-Signature"
+	defaultTimestampHeader = "X-Timestamp"
+)
+
+// Canonicalizer builds the message which is signed from request, unix timestamp and body.
+type Canonicalizer func(req *http.Request, timestamp string, body []byte) []byte
+
+// Canonical returns the default canonicalizer which signs
+//
+//	METHOD\nPATH?QUERY\nTIMESTAMP\nheader:value\n...\nhex(sha256(body))
+//
+// listed headers are included in provided order with lower case names.
+func Canonical(headers ...string) Canonicalizer {
+	return func(req *http.Request, timestamp string, body []byte) []byte {
+		var b strings.Builder
+		b.WriteString(req.Method + "\n" + req.URL.RequestURI() + "\n" + timestamp + "\n")
+		for _, k := range headers {
+			b.WriteString(strings.ToLower(k) + ":" + strings.TrimSpace(req.Header.Get(k)) + "\n")
+		}
+		b.WriteString(sha256Hex(body))
+		return []byte(b.String())
+	}
+}
+
+// TimestampBody signs "TIMESTAMP.BODY" as used by Stripe and Slack style webhooks.
+func TimestampBody(_ *http.Request, timestamp string, body []byte) []byte {
+	return append([]byte(timestamp+"."), body...)
+}
+
+// BodyOnly signs raw body as used by GitHub style webhooks, it doesn't protect against
+// replays so it should only be used when receiver requires it.
+func BodyOnly(_ *http.Request, _ string, body []byte) []byte {
+	return body
+}
+
+// HMAC signs requests with HMAC-SHA256 of canonical message.
+type HMAC struct {
+	Key []byte
+	// Header receives the signature, default is X-Signature
+	Header string
+	// Prefix is prepended to encoded signature e.g. "sha256="
+	Prefix string
+	// TimestampHeader receives the unix timestamp of signing, default is X-Timestamp
+	TimestampHeader string
+	// Canonicalize builds the signed message, default is Canonical()
+	Canonicalize Canonicalizer
+	// Encode encodes the signature, default is lower case hex
+	Encode func([]byte) string
+	// Now returns signing time, default is [time.Now]
+	Now func() time.Time
+}
+
+func (h *HMAC) defaults() (header, tsHeader string, canon Canonicalizer, encode func([]byte) string) {
+	header, tsHeader, canon, encode = h.Header, h.TimestampHeader, h.Canonicalize, h.Encode
+	if header == "" {
+		header = defaultSignatureHeader
+	}
+	if tsHeader == "" {
+		tsHeader = defaultTimestampHeader
+	}
+	if canon == nil {
+		canon = Canonical()
+	}
+	if encode == nil {
+		encode = hex.EncodeToString
+	}
+	return header, tsHeader, canon, encode
+}
+
+func (h *HMAC) now() time.Time {
+	if h.Now != nil {
+		return h.Now()
+	}
+	return time.Now()
+}
+
+// Hook signs the request, it can be used as request hook of httpx client.
+func (h *HMAC) Hook(req *http.Request) error {
+	return h.Sign(req)
+}
+
+// Sign sets the timestamp and signature headers of request.
+func (h *HMAC) Sign(req *http.Request) error {
+	header, tsHeader, canon, encode := h.defaults()
+	body, err := readBody(req)
+	if err != nil {
+		return err
+	}
+	ts := strconv.FormatInt(h.now().Unix(), 10)
+	req.Header.Set(tsHeader, ts)
+	req.Header.Set(header, h.Prefix+encode(h.sum(canon(req, ts, body))))
+	return nil
+}
+
+// Verify verifies signature of received request in constant time, timestamp must be within
+// maxSkew of current time, zero maxSkew disables the check. Body is buffered and restored so
+// handler can still read it.
+func (h *HMAC) Verify(req *http.Request, maxSkew time.Duration) error {
+	header, tsHeader, canon, encode := h.defaults()
+	got, ok := strings.CutPrefix(req.Header.Get(header), h.Prefix)
+	if !ok || got == "" {
+		return ErrMissingSignature
+	}
+	ts := req.Header.Get(tsHeader)
+	if maxSkew > 0 {
+		secs, err := strconv.ParseInt(ts, 10, 64)
+		if err != nil {
+			return ErrSignatureExpired
+		}
+		if d := h.now().Sub(time.Unix(secs, 0)); d > maxSkew || d < -maxSkew {
+			return ErrSignatureExpired
+		}
+	}
+	body, err := readBody(req)
+	if err != nil {
+		return err
+	}
+	want := encode(h.sum(canon(req, ts, body)))
+	if !hmac.Equal([]byte(got), []byte(want)) {
+		return ErrSignatureMismatch
+	}
+	return nil
+}
+
+func (h *HMAC) sum(msg []byte) []byte {
+	return hmacSHA256(h.Key, string(msg))
+}
@@ -0,0 +1,165 @@
+package signer
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"collections/httpx"
+)
+
+func fixedTime() time.Time {
+	return time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+}
+
+// TestSigV4Vanilla is get-vanilla case of AWS SigV4 test suite
+func TestSigV4Vanilla(t *testing.T) {
+	s := &SigV4{
+		Credentials: Credentials{
+			AccessKeyID:     "AKIDEXAMPLE",
+			SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+		},
+		Region:  "us-east-1",
+		Service: "service",
+		Now:     fixedTime,
+	}
+	req, _ := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	if err := s.Sign(req); err != nil {
+		t.Fatal(err)
+	}
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+		"SignedHeaders=host;x-amz-date, " +
+		"Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Errorf("wanted\n%s\ngot\n%s", want, got)
+	}
+}
+
+func TestSigV4Verify(t *testing.T) {
+	s := &SigV4{
+		Credentials: Credentials{AccessKeyID: "minio", SecretAccessKey: "secret", SessionToken: "token"},
+		Region:      "us-east-1",
+		Service:     "s3",
+	}
+	var verr error
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		verr = s.Verify(r, time.Minute)
+	}))
+	t.Cleanup(ts.Close)
+
+	c := httpx.New(false)
+	ho := httpx.NewHTTPOptions().Header("Content-Type", "text/plain").RequestHook(s.Hook)
+	res, err := c.Put(context.Background(), ts.URL+"/bucket/my key.txt?x-id=PutObject", strings.NewReader("hello"), ho)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if verr != nil {
+		t.Errorf("signed request failed verification: %v", verr)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, ts.URL+"/bucket/my key.txt", nil)
+	u, err := s.Presign(req, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err = c.Get(context.Background(), u.String(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if verr != nil {
+		t.Errorf("presigned url failed verification: %v", verr)
+	}
+
+	tampered := strings.Replace(u.String(), "my%20key", "other", 1)
+	res, err = c.Get(context.Background(), tampered, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if verr != ErrSignatureMismatch {
+		t.Errorf("wanted ErrSignatureMismatch for tampered url got %v", verr)
+	}
+}
+
+func TestHMAC(t *testing.T) {
+	h := &HMAC{Key: []byte("webhook-secret"), Header: "X-Hub-Signature-256", Prefix: "sha256=",
+		Canonicalize: Canonical("Content-Type")}
+	req, _ := http.NewRequest(http.MethodPost, "https://example.com/hook?a=1", strings.NewReader(`{"ok":true}`))
+	req.Header.Set("Content-Type", "application/json")
+	if err := h.Sign(req); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(req.Header.Get("X-Hub-Signature-256"), "sha256=") {
+		t.Fatalf("unexpected signature header %q", req.Header.Get("X-Hub-Signature-256"))
+	}
+
+	srv := httptest.NewRequest(http.MethodPost, "/hook?a=1", req.Body)
+	srv.Header = req.Header.Clone()
+	if err := h.Verify(srv, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	srv = httptest.NewRequest(http.MethodPost, "/hook?a=1", strings.NewReader(`{"ok":false}`))
+	srv.Header = req.Header.Clone()
+	if err := h.Verify(srv, time.Minute); err != ErrSignatureMismatch {
+		t.Errorf("wanted ErrSignatureMismatch got %v", err)
+	}
+
+	h.Now = func() time.Time { return time.Now().Add(time.Hour) }
+	if err := h.Verify(srv, time.Minute); err != ErrSignatureExpired {
+		t.Errorf("wanted ErrSignatureExpired got %v", err)
+	}
+}
+
+// TestSignCompressed checks that signature covers the compressed body which is sent
+func TestSignCompressed(t *testing.T) {
+	s := &SigV4{
+		Credentials: Credentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "secret"},
+		Region:      "us-east-1",
+		Service:     "service",
+	}
+	h := &HMAC{Key: []byte("webhook-secret")}
+	payload := strings.Repeat(`{"ok":true}`, 200)
+	var verr error
+	var got string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if verr = s.Verify(r, time.Minute); verr == nil {
+			verr = h.Verify(r, time.Minute)
+		}
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			verr = err
+			return
+		}
+		b, _ := io.ReadAll(zr)
+		got = string(b)
+	}))
+	t.Cleanup(ts.Close)
+
+	ho := httpx.NewHTTPOptions().
+		Compress(httpx.GzipEncoder{}, 1024).
+		RequestHook(func(req *http.Request) error {
+			if err := s.Hook(req); err != nil {
+				return err
+			}
+			return h.Hook(req)
+		})
+	res, err := httpx.New(false).Post(context.Background(), ts.URL, strings.NewReader(payload), ho)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if verr != nil {
+		t.Fatalf("compressed request failed verification: %v", verr)
+	}
+	if got != payload {
+		t.Errorf("unexpected decompressed body of %d bytes", len(got))
+	}
+}
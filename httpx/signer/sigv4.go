@@ -0,0 +1,326 @@
+package signer
+
+import (
+	"crypto/hmac"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	sigV4Algorithm = "AWS4-HMAC-SHA256"
+	amzDateFormat  = "20060102T150405Z"
+	shortDate      = "20060102"
+	// UnsignedPayload is the payload hash of requests whose body is not signed
+	UnsignedPayload = "UNSIGNED-PAYLOAD"
+	maxPresignTime  = 7 * 24 * time.Hour
+)
+
+// headers which are never signed as proxies and transports may change them
+var sigV4IgnoredHeaders = map[string]bool{
+	"authorization":     true,
+	"user-agent":        true,
+	"x-amzn-trace-id":   true,
+	"expect":            true,
+	"connection":        true,
+	"transfer-encoding": true,
+}
+
+// Credentials are the AWS access keys, SessionToken is set for temporary credentials.
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// SigV4 signs requests with AWS Signature Version 4, it works with S3 compatible stores
+// such as MinIO as well.
+type SigV4 struct {
+	Credentials Credentials
+	Region      string
+	Service     string
+	// UnsignedPayload skips hashing the body, it's required for streaming bodies which can't
+	// be read twice. S3 accepts it over https.
+	UnsignedPayload bool
+	// Now returns signing time, default is [time.Now]
+	Now func() time.Time
+}
+
+func (s *SigV4) now() time.Time {
+	if s.Now != nil {
+		return s.Now().UTC()
+	}
+	return time.Now().UTC()
+}
+
+// s3 services use single URI encoding and require X-Amz-Content-Sha256 header
+func (s *SigV4) s3() bool {
+	return s.Service == "s3" || s.Service == "s3-object-lambda"
+}
+
+// Hook signs the request, it can be used as request hook of httpx client.
+func (s *SigV4) Hook(req *http.Request) error {
+	return s.Sign(req)
+}
+
+// Sign adds X-Amz-Date and Authorization headers to request. Payload hash is taken from
+// X-Amz-Content-Sha256 header when it's already set.
+func (s *SigV4) Sign(req *http.Request) error {
+	t := s.now()
+	payload, err := s.payloadHash(req)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Amz-Date", t.Format(amzDateFormat))
+	if s.Credentials.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.Credentials.SessionToken)
+	}
+	if s.s3() {
+		req.Header.Set("X-Amz-Content-Sha256", payload)
+	}
+
+	signed := signedHeaders(req)
+	sig := s.signature(req, t, req.URL.Query(), signed, payload)
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		sigV4Algorithm, s.Credentials.AccessKeyID, s.scope(t), strings.Join(signed, ";"), sig,
+	))
+	return nil
+}
+
+// Presign returns presigned url of request valid for expires, maximum is 7 days. Only the
+// host header is signed and payload is unsigned so url can be used by any http client.
+func (s *SigV4) Presign(req *http.Request, expires time.Duration) (*url.URL, error) {
+	if expires <= 0 || expires > maxPresignTime {
+		return nil, fmt.Errorf("signer: presign expiry must be between 1s and 7 days got %s", expires)
+	}
+	t := s.now()
+	u := *req.URL
+	q := u.Query()
+	q.Set("X-Amz-Algorithm", sigV4Algorithm)
+	q.Set("X-Amz-Credential", s.Credentials.AccessKeyID+"/"+s.scope(t))
+	q.Set("X-Amz-Date", t.Format(amzDateFormat))
+	q.Set("X-Amz-Expires", strconv.Itoa(int(expires.Seconds())))
+	q.Set("X-Amz-SignedHeaders", "host")
+	if s.Credentials.SessionToken != "" {
+		q.Set("X-Amz-Security-Token", s.Credentials.SessionToken)
+	}
+
+	r := req.Clone(req.Context())
+	r.URL = &u
+	sig := s.signature(r, t, q, []string{"host"}, UnsignedPayload)
+	q.Set("X-Amz-Signature", sig)
+	u.RawQuery = canonicalQuery(q)
+	return &u, nil
+}
+
+// Verify verifies the request signed by [SigV4.Sign] or presigned url on the receiving side.
+// Access key, region and service of signature must match the signer and signing time must
+// be within maxSkew of current time.
+func (s *SigV4) Verify(req *http.Request, maxSkew time.Duration) error {
+	q := req.URL.Query()
+	var credential, date, sig, payload string
+	var signed []string
+	if auth := req.Header.Get("Authorization"); auth != "" {
+		rest, ok := strings.CutPrefix(auth, sigV4Algorithm+" ")
+		if !ok {
+			return ErrMissingSignature
+		}
+		for part := range strings.SplitSeq(rest, ",") {
+			k, v, _ := strings.Cut(strings.TrimSpace(part), "=")
+			switch k {
+			case "Credential":
+				credential = v
+			case "SignedHeaders":
+				signed = strings.Split(v, ";")
+			case "Signature":
+				sig = v
+			}
+		}
+		date = req.Header.Get("X-Amz-Date")
+		payload = req.Header.Get("X-Amz-Content-Sha256")
+		if payload != UnsignedPayload {
+			body, err := readBody(req)
+			if err != nil {
+				return err
+			}
+			if sum := sha256Hex(body); payload == "" {
+				payload = sum
+			} else if sum != payload {
+				return fmt.Errorf("%w: payload hash doesn't match body", ErrSignatureMismatch)
+			}
+		}
+	} else if sig = q.Get("X-Amz-Signature"); sig != "" {
+		credential = q.Get("X-Amz-Credential")
+		signed = strings.Split(q.Get("X-Amz-SignedHeaders"), ";")
+		date = q.Get("X-Amz-Date")
+		payload = UnsignedPayload
+		q.Del("X-Amz-Signature")
+	} else {
+		return ErrMissingSignature
+	}
+
+	t, err := time.Parse(amzDateFormat, date)
+	if err != nil {
+		return fmt.Errorf("signer: invalid X-Amz-Date: %w", err)
+	}
+	now := s.now()
+	if exp := q.Get("X-Amz-Expires"); exp != "" {
+		secs, err := strconv.Atoi(exp)
+		if err != nil || now.After(t.Add(time.Duration(secs)*time.Second)) {
+			return ErrSignatureExpired
+		}
+	} else if now.Sub(t) > maxSkew || t.Sub(now) > maxSkew {
+		return ErrSignatureExpired
+	}
+	if credential != s.Credentials.AccessKeyID+"/"+s.scope(t) {
+		return fmt.Errorf("%w: unexpected credential scope %s", ErrSignatureMismatch, credential)
+	}
+	if !slices.Contains(signed, "host") {
+		return errors.New("signer: host header is not signed")
+	}
+
+	want := s.signature(req, t, q, signed, payload)
+	if !hmac.Equal([]byte(want), []byte(sig)) {
+		return ErrSignatureMismatch
+	}
+	return nil
+}
+
+func (s *SigV4) scope(t time.Time) string {
+	return t.Format(shortDate) + "/" + s.Region + "/" + s.Service + "/aws4_request"
+}
+
+// payloadHash returns hex encoded sha256 of body or the unsigned payload marker
+func (s *SigV4) payloadHash(req *http.Request) (string, error) {
+	if v := req.Header.Get("X-Amz-Content-Sha256"); v != "" {
+		return v, nil
+	}
+	if s.UnsignedPayload {
+		return UnsignedPayload, nil
+	}
+	body, err := readBody(req)
+	if err != nil {
+		return "", err
+	}
+	return sha256Hex(body), nil
+}
+
+// signature computes the signature of canonical request
+func (s *SigV4) signature(req *http.Request, t time.Time, q url.Values, signed []string, payload string) string {
+	canonical := strings.Join([]string{
+		req.Method,
+		s.canonicalURI(req.URL),
+		canonicalQuery(q),
+		canonicalHeaders(req, signed),
+		strings.Join(signed, ";"),
+		payload,
+	}, "\n")
+	toSign := strings.Join([]string{
+		sigV4Algorithm,
+		t.Format(amzDateFormat),
+		s.scope(t),
+		sha256Hex([]byte(canonical)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.Credentials.SecretAccessKey), t.Format(shortDate))
+	key = hmacSHA256(key, s.Region)
+	key = hmacSHA256(key, s.Service)
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, toSign))
+}
+
+// canonicalURI encodes the path once for s3 and twice for other services
+func (s *SigV4) canonicalURI(u *url.URL) string {
+	path := u.Path
+	if path == "" {
+		return "/"
+	}
+	path = awsEscape(path, false)
+	if !s.s3() {
+		path = awsEscape(path, false)
+	}
+	return path
+}
+
+// canonicalQuery sorts and encodes the query, key and values are sorted
+func canonicalQuery(q url.Values) string {
+	pairs := make([]string, 0, len(q))
+	for k, vs := range q {
+		for _, v := range vs {
+			pairs = append(pairs, awsEscape(k, true)+"="+awsEscape(v, true))
+		}
+	}
+	slices.Sort(pairs)
+	return strings.Join(pairs, "&")
+}
+
+// canonicalHeaders returns the signed headers, each header ends with new line
+func canonicalHeaders(req *http.Request, signed []string) string {
+	var b strings.Builder
+	for _, k := range signed {
+		var values []string
+		if k == "host" {
+			values = []string{host(req)}
+		} else {
+			for _, v := range req.Header.Values(k) {
+				values = append(values, strings.Join(strings.Fields(v), " "))
+			}
+		}
+		b.WriteString(k + ":" + strings.Join(values, ",") + "\n")
+	}
+	return b.String()
+}
+
+// signedHeaders returns sorted lower case names of headers to be signed including host
+func signedHeaders(req *http.Request) []string {
+	signed := []string{"host"}
+	for k := range req.Header {
+		lk := strings.ToLower(k)
+		if !sigV4IgnoredHeaders[lk] && lk != "host" {
+			signed = append(signed, lk)
+		}
+	}
+	slices.Sort(signed)
+	return signed
+}
+
+// host returns request host without default port
+func host(req *http.Request) string {
+	h := req.Host
+	if h == "" {
+		h = req.URL.Host
+	}
+	if p := strings.LastIndexByte(h, ':'); p > 0 && !strings.Contains(h[p:], "]") {
+		port := h[p+1:]
+		if port == "80" && req.URL.Scheme == "http" || port == "443" && req.URL.Scheme == "https" {
+			h = h[:p]
+		}
+	}
+	return h
+}
+
+// awsEscape encodes everything except unreserved characters as required by SigV4,
+// slash is escaped only if encodeSlash is set.
+func awsEscape(s string, encodeSlash bool) string {
+	const hexChars = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '_' || c == '.' || c == '~' || c == '/' && !encodeSlash {
+			b.WriteByte(c)
+			continue
+		}
+		b.WriteByte('%')
+		b.WriteByte(hexChars[c>>4])
+		b.WriteByte(hexChars[c&15])
+	}
+	return b.String()
+}
@@ -0,0 +1,176 @@
+package sse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"mime"
+	"net/http"
+	"time"
+
+	"collections/httpx"
+	"collections/httpx/hooks"
+)
+
+const (
+	// defaultRetry is reconnection time used when server didn't send retry field and no backoff is set
+	defaultRetry        = 3 * time.Second
+	defaultMaxEventSize = 1024 * 1024
+)
+
+// StatusError is returned when server responds with status other than 200 or with content type
+// other than text/event-stream. Client doesn't reconnect after it as specified by EventSource.
+type StatusError struct {
+	StatusCode  int
+	ContentType string
+}
+
+func (e StatusError) Error() string {
+	return fmt.Sprintf("sse: unexpected response status=%d, content_type=%s", e.StatusCode, e.ContentType)
+}
+
+// Options configures the [Client].
+type Options struct {
+	// HTTPOptions returns options for every connection attempt, it can be used for setting
+	// auth headers or hooks. Accept, Cache-Control and Last-Event-ID headers are set by client.
+	HTTPOptions func() *httpx.HTTPOptions
+	// LastEventID is sent with first connection for resuming the stream
+	LastEventID string
+	// Backoff is used for reconnect delay when server didn't send retry field.
+	// If nil the default 3 seconds reconnection time is used.
+	Backoff *hooks.BackoffWithJitter
+	// MaxRetries is maximum consecutive failed reconnects before giving up, zero is unlimited
+	MaxRetries int
+	// MaxEventSize is the maximum size of single line of stream, default is 1MB
+	MaxEventSize int
+}
+
+// Client is Server-Sent Events client which reconnects with Last-Event-ID header
+// whenever the stream ends or connection fails.
+type Client struct {
+	c    *httpx.Client
+	uri  string
+	opts Options
+}
+
+func New(c *httpx.Client, uri string, opts Options) *Client {
+	if opts.MaxEventSize <= 0 {
+		opts.MaxEventSize = defaultMaxEventSize
+	}
+	return &Client{c: c, uri: uri, opts: opts}
+}
+
+// Events returns iterator over events of the stream. Connection errors are yielded along
+// with empty event and client reconnects after them, breaking the loop stops the stream.
+// Iteration ends when context is done, server responds with 204 No Content,
+// [StatusError] is yielded or reconnects are exhausted.
+func (s *Client) Events(ctx context.Context) iter.Seq2[Event, error] {
+	return func(yield func(Event, error) bool) {
+		st := &state{lastID: s.opts.LastEventID}
+		failures := 0
+		for {
+			received, err := s.stream(ctx, st, func(evt Event) bool {
+				return yield(evt, nil)
+			})
+			if errors.Is(err, errStop) {
+				return
+			}
+			if ctx.Err() != nil {
+				return
+			}
+			var se StatusError
+			if errors.As(err, &se) {
+				yield(Event{}, err)
+				return
+			}
+			if err != nil && !yield(Event{}, err) {
+				return
+			}
+
+			if received {
+				failures = 0
+			}
+			failures++
+			if s.opts.MaxRetries > 0 && failures > s.opts.MaxRetries {
+				return
+			}
+
+			wait := st.retry
+			if wait == 0 {
+				wait = defaultRetry
+				if s.opts.Backoff != nil {
+					wait = s.opts.Backoff.NextWaitDuration(nil, failures)
+				}
+			}
+			t := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				t.Stop()
+				return
+			case <-t.C:
+			}
+		}
+	}
+}
+
+// errStop is returned by stream when consumer stopped the iteration or server asked to stop.
+var errStop = errors.New("sse: stop")
+
+// state is carried between connections of the stream.
+type state struct {
+	lastID string
+	retry  time.Duration
+}
+
+// stream connects and dispatches events to fn till stream ends, it reports whether
+// any event was received so consecutive failures can be reset.
+func (s *Client) stream(ctx context.Context, st *state, fn func(Event) bool) (bool, error) {
+	ho := httpx.NewHTTPOptions()
+	if s.opts.HTTPOptions != nil {
+		ho = s.opts.HTTPOptions()
+	}
+	ho.Header("Accept", "text/event-stream").Header("Cache-Control", "no-cache")
+	if st.lastID != "" {
+		ho.Header("Last-Event-ID", st.lastID)
+	}
+
+	res, err := s.c.Get(ctx, s.uri, ho)
+	if err != nil {
+		return false, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNoContent {
+		return false, errStop
+	}
+	mt, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	if res.StatusCode != http.StatusOK || mt != "text/event-stream" {
+		return false, StatusError{StatusCode: res.StatusCode, ContentType: res.Header.Get("Content-Type")}
+	}
+
+	p := newParser(res.Body, s.opts.MaxEventSize)
+	p.lastID = st.lastID
+	defer func() {
+		st.lastID = p.lastID
+		if p.retry > 0 {
+			st.retry = p.retry
+		}
+	}()
+
+	received := false
+	for {
+		evt, err := p.next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return received, nil
+			}
+			return received, err
+		}
+		received = true
+		if !fn(evt) {
+			return received, errStop
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package sse
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"collections/httpx"
+)
+
+func TestEvents(t *testing.T) {
+	var lastIDs []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastIDs = append(lastIDs, r.Header.Get("Last-Event-ID"))
+		w.Header().Set("Content-Type", "text/event-stream")
+		switch len(lastIDs) {
+		case 1:
+			fmt.Fprint(w, "\ufeff: comment\r\nretry: 1\r\nid: 1\r\nevent: greet\r\ndata: hello\r\ndata:  world\r\n\r\n")
+			fmt.Fprint(w, "id: 2\ndata\n\nid: 3\n\n")
+		case 2:
+			fmt.Fprint(w, "data: after reconnect\r\r")
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	t.Cleanup(ts.Close)
+
+	var got []Event
+	for evt, err := range New(httpx.New(false), ts.URL, Options{}).Events(context.Background()) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, evt)
+	}
+
+	want := []Event{
+		{ID: "1", Event: "greet", Data: "hello\n world", Retry: time.Millisecond},
+		{ID: "2", Event: "message", Data: ""},
+		{ID: "3", Event: "message", Data: "after reconnect"},
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("wanted %v got %v", want, got)
+	}
+	if fmt.Sprint(lastIDs) != "[ 3 3]" {
+		t.Errorf("unexpected Last-Event-ID headers %q", lastIDs)
+	}
+}
@@ -0,0 +1,3 @@
+// Package sse contains Server-Sent Events (EventSource) client built on httpx client
+// which reconnects automatically with Last-Event-ID
+package sse
@@ -0,0 +1,120 @@
+package sse
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Event is single event dispatched by server.
+type Event struct {
+	// ID is the last event id at the time of dispatch
+	ID string
+	// Event is the event type, "message" if not provided by server
+	Event string
+	Data  string
+	// Retry is the reconnection time sent along with the event, zero if not sent
+	Retry time.Duration
+}
+
+// parser parses text/event-stream as specified in
+// https://html.spec.whatwg.org/multipage/server-sent-events.html#event-stream-interpretation
+type parser struct {
+	sc      *bufio.Scanner
+	lastID  string
+	retry   time.Duration
+	first   bool
+	evtType string
+	data    strings.Builder
+}
+
+func newParser(r io.Reader, maxEventSize int) *parser {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 4096), maxEventSize)
+	sc.Split(scanLines)
+	return &parser{sc: sc, first: true}
+}
+
+// next returns next dispatched event, io.EOF is returned once stream ends.
+func (p *parser) next() (Event, error) {
+	var retry time.Duration
+	for p.sc.Scan() {
+		line := p.sc.Text()
+		if p.first {
+			// leading byte order mark is ignored
+			line = strings.TrimPrefix(line, "\ufeff")
+			p.first = false
+		}
+		if line == "" {
+			if p.data.Len() == 0 {
+				p.evtType = ""
+				continue
+			}
+			evt := Event{
+				ID:    p.lastID,
+				Event: p.evtType,
+				Data:  strings.TrimSuffix(p.data.String(), "\n"),
+				Retry: retry,
+			}
+			if evt.Event == "" {
+				evt.Event = "message"
+			}
+			p.evtType = ""
+			p.data.Reset()
+			return evt, nil
+		}
+		if line[0] == ':' {
+			continue
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			p.evtType = value
+		case "data":
+			p.data.WriteString(value)
+			p.data.WriteByte('\n')
+		case "id":
+			if !strings.ContainsRune(value, 0) {
+				p.lastID = value
+			}
+		case "retry":
+			if ms, err := strconv.ParseUint(value, 10, 63); err == nil {
+				retry = time.Duration(ms) * time.Millisecond
+				p.retry = retry
+			}
+		}
+	}
+	if err := p.sc.Err(); err != nil {
+		return Event{}, err
+	}
+	// incomplete event at the end of stream is discarded
+	return Event{}, io.EOF
+}
+
+// scanLines splits on CRLF, LF and CR line endings.
+func scanLines(data []byte, atEOF bool) (int, []byte, error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		if data[i] == '\n' {
+			return i + 1, data[:i], nil
+		}
+		// CR might be followed by LF in next read
+		if i+1 == len(data) && !atEOF {
+			return 0, nil, nil
+		}
+		if i+1 < len(data) && data[i+1] == '\n' {
+			return i + 2, data[:i], nil
+		}
+		return i + 1, data[:i], nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
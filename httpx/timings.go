@@ -0,0 +1,146 @@
+package httpx
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// Timing is the timing breakdown of single round trip.
+//
+// BodyTransfer and Total are only final once the response body is read
+// till EOF or closed, until then Total is the time till response headers.
+type Timing struct {
+	// Attempt is the attempt number of round trip, redirects share the attempt number
+	Attempt         int
+	URL             string
+	DNSLookup       time.Duration
+	Connect         time.Duration
+	TLSHandshake    time.Duration
+	TimeToFirstByte time.Duration
+	BodyTransfer    time.Duration
+	Total           time.Duration
+	ConnReused      bool
+	RemoteAddr      string
+	Err             error
+}
+
+// Timings records timing breakdown for every round trip performed by single Exec call,
+// including the attempts of retry hook and followed redirects.
+// It is safe to read Timings while response body is still being read.
+type Timings struct {
+	mu     sync.Mutex
+	rounds []*Timing
+}
+
+// Attempts returns copy of timings for every round trip in order they were performed.
+func (t *Timings) Attempts() []Timing {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := make([]Timing, 0, len(t.rounds))
+	for _, r := range t.rounds {
+		out = append(out, *r)
+	}
+	return out
+}
+
+// Last returns timing of last round trip which produced final response.
+func (t *Timings) Last() Timing {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.rounds) == 0 {
+		return Timing{}
+	}
+	return *t.rounds[len(t.rounds)-1]
+}
+
+// begin registers new round trip and returns the recorder for it.
+func (t *Timings) begin(req *http.Request, attempt int) *timingRecorder {
+	rt := &Timing{Attempt: attempt, URL: req.URL.String()}
+	t.mu.Lock()
+	t.rounds = append(t.rounds, rt)
+	t.mu.Unlock()
+	return &timingRecorder{timings: t, timing: rt, start: time.Now()}
+}
+
+// timingRecorder records single round trip into [Timings] using [net/http/httptrace].
+type timingRecorder struct {
+	timings      *Timings
+	timing       *Timing
+	start        time.Time
+	dnsStart     time.Time
+	connectStart time.Time
+	tlsStart     time.Time
+	firstByte    time.Time
+}
+
+func (r *timingRecorder) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			r.set(func() { r.dnsStart = time.Now() })
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			r.set(func() { r.timing.DNSLookup = time.Since(r.dnsStart) })
+		},
+		ConnectStart: func(string, string) {
+			r.set(func() {
+				if r.connectStart.IsZero() {
+					r.connectStart = time.Now()
+				}
+			})
+		},
+		ConnectDone: func(_, _ string, err error) {
+			if err != nil {
+				return
+			}
+			r.set(func() { r.timing.Connect = time.Since(r.connectStart) })
+		},
+		TLSHandshakeStart: func() {
+			r.set(func() { r.tlsStart = time.Now() })
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			r.set(func() { r.timing.TLSHandshake = time.Since(r.tlsStart) })
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			r.set(func() {
+				r.timing.ConnReused = info.Reused
+				if info.Conn != nil {
+					r.timing.RemoteAddr = info.Conn.RemoteAddr().String()
+				}
+			})
+		},
+		GotFirstResponseByte: func() {
+			r.set(func() {
+				r.firstByte = time.Now()
+				r.timing.TimeToFirstByte = r.firstByte.Sub(r.start)
+			})
+		},
+	}
+}
+
+// gotResponse records the time till response headers or the round trip error.
+func (r *timingRecorder) gotResponse(err error) {
+	r.set(func() {
+		r.timing.Total = time.Since(r.start)
+		r.timing.Err = err
+	})
+}
+
+// bodyDone records body transfer and total time once response body is consumed.
+func (r *timingRecorder) bodyDone() {
+	r.set(func() {
+		now := time.Now()
+		if !r.firstByte.IsZero() {
+			r.timing.BodyTransfer = now.Sub(r.firstByte)
+		}
+		r.timing.Total = now.Sub(r.start)
+	})
+}
+
+func (r *timingRecorder) set(f func()) {
+	r.timings.mu.Lock()
+	f()
+	r.timings.mu.Unlock()
+}
@@ -0,0 +1,96 @@
+package httpx
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTimings(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/flaky" && calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("first"))
+		w.(http.Flusher).Flush()
+		time.Sleep(20 * time.Millisecond)
+		w.Write([]byte("second"))
+	}))
+	defer srv.Close()
+
+	// localhost is resolved so DNS phase is recorded, certificate is issued for example.com
+	tr := srv.Client().Transport.(*http.Transport).Clone()
+	tr.TLSClientConfig.ServerName = "example.com"
+	c := New(false).SetTransport(tr)
+	url := strings.Replace(srv.URL, "127.0.0.1", "localhost", 1)
+
+	var first Timings
+	res, err := c.Get(context.Background(), url, NewHTTPOptions().Timings(&first))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := first.Last()
+	if got.Attempt != 1 || got.DNSLookup <= 0 || got.Connect <= 0 || got.TLSHandshake <= 0 ||
+		got.TimeToFirstByte <= 0 || got.ConnReused || got.RemoteAddr == "" {
+		t.Fatalf("unexpected timing of new connection %+v", got)
+	}
+	if got.BodyTransfer != 0 || got.Total <= 0 {
+		t.Fatalf("body is not consumed yet, got %+v", got)
+	}
+	headers := got.Total
+	if _, err := io.ReadAll(res.Body); err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	got = first.Last()
+	if got.BodyTransfer < 20*time.Millisecond || got.Total <= headers {
+		t.Fatalf("body transfer is not recorded, got %+v", got)
+	}
+	if got.Total < got.TimeToFirstByte+got.BodyTransfer {
+		t.Fatalf("total %s is less than its phases %+v", got.Total, got)
+	}
+
+	var second Timings
+	res, err = c.Get(context.Background(), url, NewHTTPOptions().Timings(&second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.Copy(io.Discard, res.Body)
+	res.Body.Close()
+	if got := second.Last(); !got.ConnReused || got.DNSLookup != 0 || got.Connect != 0 || got.TLSHandshake != 0 {
+		t.Fatalf("unexpected timing of reused connection %+v", got)
+	}
+
+	var retried Timings
+	ho := NewHTTPOptions().Timings(&retried).
+		RetryHook(func(req *http.Request, res *http.Response, hc *http.Client, err error) (*http.Response, error) {
+			if err != nil || res.StatusCode != http.StatusServiceUnavailable {
+				return res, err
+			}
+			io.Copy(io.Discard, res.Body)
+			res.Body.Close()
+			return hc.Do(req)
+		})
+	res, err = c.Get(context.Background(), url+"/flaky", ho)
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.Copy(io.Discard, res.Body)
+	res.Body.Close()
+	attempts := retried.Attempts()
+	if len(attempts) != 2 || attempts[0].Attempt != 1 || attempts[1].Attempt != 2 {
+		t.Fatalf("want record per attempt, got %+v", attempts)
+	}
+	for _, a := range attempts {
+		if !strings.HasSuffix(a.URL, "/flaky") || a.Total <= 0 || a.Err != nil {
+			t.Errorf("unexpected attempt %+v", a)
+		}
+	}
+}
@@ -0,0 +1,4 @@
+// Package tracecontext contains dependency free W3C Trace Context and Baggage propagation
+// for outgoing requests along with transport which reports spans to pluggable exporter.
+// [Hooks] report single span for all the attempts of request through httpx hooks.
+package tracecontext
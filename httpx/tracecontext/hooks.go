@@ -0,0 +1,102 @@
+package tracecontext
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"collections/httpx"
+)
+
+// Hooks reports single span for all the attempts of request through httpx v2 hooks, use
+// [Transport] for span of every attempt. Start is request hook, End is response hook and
+// Retry wraps retry hook so failed requests are reported too, response hook is not called
+// by [httpx.Client] when retry hook is set or request failed.
+//
+//	h := &tracecontext.Hooks{Exporter: exporter}
+//	ho := httpx.NewHTTPOptions().RequestHookV2(h.Start).RetryHookV2(h.Retry(nil))
+type Hooks struct {
+	// Exporter receives span of every request, spans are only propagated if nil
+	Exporter Exporter
+	// SpanName returns name of span, default is "HTTP {method}"
+	SpanName func(*http.Request) string
+
+	// spans are the started spans by their span id
+	spans sync.Map
+}
+
+// Start injects child span of span in ctx into request and reports its start.
+func (h *Hooks) Start(ctx context.Context, _ httpx.RequestInfo, req *http.Request) error {
+	parent, ok := SpanContextFromContext(ctx)
+	sc := parent.Child()
+	if !ok {
+		sc = NewRoot()
+	}
+	Inject(req, sc)
+	if h.Exporter == nil {
+		return nil
+	}
+
+	name := "HTTP " + req.Method
+	if h.SpanName != nil {
+		name = h.SpanName(req)
+	}
+	span := Span{
+		Name:        name,
+		SpanContext: sc,
+		Parent:      parent.SpanID,
+		Method:      req.Method,
+		URL:         req.URL.String(),
+		Start:       time.Now(),
+	}
+	h.spans.Store(sc.SpanID, span)
+	h.Exporter.SpanStart(span)
+	return nil
+}
+
+// End reports end of span started for request with status of response.
+func (h *Hooks) End(_ context.Context, _ httpx.RequestInfo, req *http.Request, res *http.Response) error {
+	h.end(req, res, nil)
+	return nil
+}
+
+// Retry returns retry hook which calls next and reports end of span with its result,
+// nil next returns the response or error as is.
+func (h *Hooks) Retry(next httpx.RetryHookV2) httpx.RetryHookV2 {
+	return func(
+		ctx context.Context,
+		info httpx.RequestInfo,
+		req *http.Request,
+		res *http.Response,
+		hc *http.Client,
+		err error,
+	) (*http.Response, error) {
+		if next != nil {
+			res, err = next(ctx, info, req, res, hc, err)
+		}
+		h.end(req, res, err)
+		return res, err
+	}
+}
+
+func (h *Hooks) end(req *http.Request, res *http.Response, err error) {
+	if h.Exporter == nil {
+		return
+	}
+	sc, ok := Extract(req.Header)
+	if !ok {
+		return
+	}
+	v, ok := h.spans.LoadAndDelete(sc.SpanID)
+	if !ok {
+		return
+	}
+	span := v.(Span)
+	span.End = time.Now()
+	span.Err = err
+	if res != nil {
+		span.StatusCode = res.StatusCode
+	}
+	h.Exporter.SpanEnd(span)
+}
@@ -0,0 +1,56 @@
+package tracecontext
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"collections/httpx"
+)
+
+// TestHooks checks span of request is exported through client hooks
+func TestHooks(t *testing.T) {
+	parent, _ := ParseTraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx := ContextWithSpanContext(context.Background(), parent)
+
+	var sent string
+	c := httpx.New(false).SetTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if strings.HasSuffix(req.URL.Path, "/fail") {
+			return nil, errors.New("connection refused")
+		}
+		sent = req.Header.Get(HeaderTraceParent)
+		return &http.Response{StatusCode: http.StatusCreated, Header: make(http.Header), Body: http.NoBody}, nil
+	}))
+	rec := &recorder{}
+	h := &Hooks{Exporter: rec}
+
+	res, err := c.Get(ctx, "http://example.com/ok", httpx.NewHTTPOptions().RequestHookV2(h.Start).ResponseHookV2(h.End))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if len(rec.start) != 1 || len(rec.end) != 1 {
+		t.Fatalf("wanted single span got %d starts and %d ends", len(rec.start), len(rec.end))
+	}
+	span := rec.end[0]
+	if span.SpanContext != rec.start[0].SpanContext || span.SpanContext.TraceParent() != sent {
+		t.Errorf("exported span %s doesn't match sent traceparent %s", span.SpanContext.TraceParent(), sent)
+	}
+	if span.SpanContext.TraceID != parent.TraceID || span.Parent != parent.SpanID {
+		t.Errorf("span is not child of %s", parent.TraceParent())
+	}
+	if span.Name != "HTTP GET" || span.StatusCode != http.StatusCreated || span.End.Before(span.Start) {
+		t.Errorf("unexpected span %+v", span)
+	}
+
+	// failed request is only seen by retry hook
+	_, err = c.Get(ctx, "http://example.com/fail", httpx.NewHTTPOptions().RequestHookV2(h.Start).RetryHookV2(h.Retry(nil)))
+	if err == nil {
+		t.Fatal("wanted error")
+	}
+	if len(rec.end) != 2 || rec.end[1].Err == nil || rec.end[1].StatusCode != 0 {
+		t.Fatalf("failed request is not exported %+v", rec.end)
+	}
+}
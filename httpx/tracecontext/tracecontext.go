@@ -0,0 +1,229 @@
+package tracecontext
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+const (
+	HeaderTraceParent = "traceparent"
+	HeaderTraceState  = "tracestate"
+	HeaderBaggage     = "baggage"
+)
+
+// FlagSampled is the sampled bit of trace flags.
+const FlagSampled byte = 0x01
+
+var ErrInvalidTraceParent = errors.New("invalid traceparent")
+
+// TraceID is 16 byte trace identifier.
+type TraceID [16]byte
+
+func (t TraceID) String() string { return hex.EncodeToString(t[:]) }
+
+// IsValid returns false for all zero trace id.
+func (t TraceID) IsValid() bool { return t != TraceID{} }
+
+// SpanID is 8 byte span identifier.
+type SpanID [8]byte
+
+func (s SpanID) String() string { return hex.EncodeToString(s[:]) }
+
+// IsValid returns false for all zero span id.
+func (s SpanID) IsValid() bool { return s != SpanID{} }
+
+// SpanContext is the propagated part of span as defined by W3C Trace Context.
+type SpanContext struct {
+	TraceID    TraceID
+	SpanID     SpanID
+	Flags      byte
+	TraceState string
+}
+
+// IsValid reports whether both trace id and span id are valid.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+// IsSampled reports whether sampled flag is set.
+func (sc SpanContext) IsSampled() bool {
+	return sc.Flags&FlagSampled != 0
+}
+
+// TraceParent returns the traceparent header value of span context.
+func (sc SpanContext) TraceParent() string {
+	return fmt.Sprintf("00-%s-%s-%02x", sc.TraceID, sc.SpanID, sc.Flags)
+}
+
+// Child returns span context with same trace and new span id.
+func (sc SpanContext) Child() SpanContext {
+	sc.SpanID = NewSpanID()
+	return sc
+}
+
+// NewRoot returns sampled span context of new trace.
+func NewRoot() SpanContext {
+	return SpanContext{TraceID: NewTraceID(), SpanID: NewSpanID(), Flags: FlagSampled}
+}
+
+func NewTraceID() TraceID {
+	var t TraceID
+	for !t.IsValid() {
+		_, _ = rand.Read(t[:])
+	}
+	return t
+}
+
+func NewSpanID() SpanID {
+	var s SpanID
+	for !s.IsValid() {
+		_, _ = rand.Read(s[:])
+	}
+	return s
+}
+
+// ParseTraceParent parses the traceparent header value.
+// Versions higher than 00 are parsed as 00 with extra fields ignored as required by spec.
+func ParseTraceParent(v string) (SpanContext, error) {
+	v = strings.TrimSpace(v)
+	// 00-{32 hex}-{16 hex}-{2 hex}
+	if len(v) < 55 || v[2] != '-' || v[35] != '-' || v[52] != '-' {
+		return SpanContext{}, ErrInvalidTraceParent
+	}
+	version, err := hexByte(v[0:2])
+	if err != nil || version == 0xff {
+		return SpanContext{}, ErrInvalidTraceParent
+	}
+	if version == 0 && len(v) != 55 {
+		return SpanContext{}, ErrInvalidTraceParent
+	}
+	if len(v) > 55 && v[55] != '-' {
+		return SpanContext{}, ErrInvalidTraceParent
+	}
+
+	var sc SpanContext
+	if !isLowerHex(v[3:35]) || !isLowerHex(v[36:52]) {
+		return SpanContext{}, ErrInvalidTraceParent
+	}
+	_, _ = hex.Decode(sc.TraceID[:], []byte(v[3:35]))
+	_, _ = hex.Decode(sc.SpanID[:], []byte(v[36:52]))
+	if sc.Flags, err = hexByte(v[53:55]); err != nil || !sc.IsValid() {
+		return SpanContext{}, ErrInvalidTraceParent
+	}
+	return sc, nil
+}
+
+// Extract returns span context from traceparent and tracestate headers of incoming request.
+func Extract(h http.Header) (SpanContext, bool) {
+	sc, err := ParseTraceParent(h.Get(HeaderTraceParent))
+	if err != nil {
+		return SpanContext{}, false
+	}
+	sc.TraceState = strings.Join(h.Values(HeaderTraceState), ",")
+	return sc, true
+}
+
+// Inject sets traceparent, tracestate and baggage headers of the request
+// for span context and baggage carried by request context.
+func Inject(req *http.Request, sc SpanContext) {
+	req.Header.Set(HeaderTraceParent, sc.TraceParent())
+	if sc.TraceState != "" {
+		req.Header.Set(HeaderTraceState, sc.TraceState)
+	} else {
+		req.Header.Del(HeaderTraceState)
+	}
+	if b := BaggageFromContext(req.Context()); len(b) > 0 {
+		req.Header.Set(HeaderBaggage, b.String())
+	}
+}
+
+type spanContextKey struct{}
+
+// ContextWithSpanContext returns context carrying span context as the parent of outgoing requests.
+func ContextWithSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, spanContextKey{}, sc)
+}
+
+// SpanContextFromContext returns span context carried by context.
+func SpanContextFromContext(ctx context.Context) (SpanContext, bool) {
+	sc, ok := ctx.Value(spanContextKey{}).(SpanContext)
+	return sc, ok && sc.IsValid()
+}
+
+// Baggage is the set of key value pairs propagated with baggage header.
+type Baggage map[string]string
+
+// String returns the baggage header value with percent encoded values.
+func (b Baggage) String() string {
+	keys := make([]string, 0, len(b))
+	for k := range b {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var sb strings.Builder
+	for i, k := range keys {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(k)
+		sb.WriteByte('=')
+		sb.WriteString(url.PathEscape(b[k]))
+	}
+	return sb.String()
+}
+
+// ParseBaggage parses the baggage header value, member properties are dropped.
+func ParseBaggage(v string) Baggage {
+	b := make(Baggage)
+	for member := range strings.SplitSeq(v, ",") {
+		member, _, _ = strings.Cut(member, ";")
+		k, val, ok := strings.Cut(member, "=")
+		k = strings.TrimSpace(k)
+		if !ok || k == "" {
+			continue
+		}
+		if unescaped, err := url.PathUnescape(strings.TrimSpace(val)); err == nil {
+			b[k] = unescaped
+		}
+	}
+	return b
+}
+
+type baggageKey struct{}
+
+// ContextWithBaggage returns context carrying baggage for outgoing requests.
+func ContextWithBaggage(ctx context.Context, b Baggage) context.Context {
+	return context.WithValue(ctx, baggageKey{}, b)
+}
+
+// BaggageFromContext returns baggage carried by context.
+func BaggageFromContext(ctx context.Context) Baggage {
+	b, _ := ctx.Value(baggageKey{}).(Baggage)
+	return b
+}
+
+func hexByte(s string) (byte, error) {
+	if !isLowerHex(s) {
+		return 0, ErrInvalidTraceParent
+	}
+	var b [1]byte
+	_, err := hex.Decode(b[:], []byte(s))
+	return b[0], err
+}
+
+func isLowerHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
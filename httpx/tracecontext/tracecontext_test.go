@@ -0,0 +1,98 @@
+package tracecontext
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestParseTraceParent(t *testing.T) {
+	cases := []struct {
+		name  string
+		value string
+		valid bool
+	}{
+		{"valid", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true},
+		{"future-version", "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", true},
+		{"invalid-version", "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false},
+		{"zero-trace-id", "00-00000000000000000000000000000000-00f067aa0ba902b7-01", false},
+		{"upper-hex", "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", false},
+		{"extra-for-v00", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", false},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			sc, err := ParseTraceParent(tt.value)
+			if (err == nil) != tt.valid {
+				t.Fatalf("wanted valid=%v got err %v", tt.valid, err)
+			}
+			if tt.valid && sc.TraceParent() != "00"+tt.value[2:55] {
+				t.Errorf("round trip mismatch got %s", sc.TraceParent())
+			}
+		})
+	}
+}
+
+type recorder struct {
+	mu    sync.Mutex
+	start []Span
+	end   []Span
+}
+
+func (r *recorder) SpanStart(s Span) { r.mu.Lock(); r.start = append(r.start, s); r.mu.Unlock() }
+func (r *recorder) SpanEnd(s Span)   { r.mu.Lock(); r.end = append(r.end, s); r.mu.Unlock() }
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+// TestTransport checks child span is created for every attempt with propagated headers
+func TestTransport(t *testing.T) {
+	parent, _ := ParseTraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	parent.TraceState = "vendor=abc"
+	ctx := ContextWithSpanContext(context.Background(), parent)
+	ctx = ContextWithBaggage(ctx, Baggage{"user": "a b", "tenant": "t1"})
+
+	var got []http.Header
+	rec := &recorder{}
+	tr := &Transport{
+		Exporter: rec,
+		Base: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			got = append(got, req.Header)
+			return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(""))}, nil
+		}),
+	}
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://example.com", nil)
+	for range 2 {
+		if _, err := tr.RoundTrip(req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if req.Header.Get(HeaderTraceParent) != "" {
+		t.Fatal("original request must not be modified")
+	}
+
+	ids := map[string]bool{}
+	for _, h := range got {
+		sc, ok := Extract(h)
+		if !ok || sc.TraceID != parent.TraceID || sc.SpanID == parent.SpanID {
+			t.Fatalf("wanted child of %s got %s", parent.TraceParent(), h.Get(HeaderTraceParent))
+		}
+		ids[sc.SpanID.String()] = true
+		if sc.TraceState != "vendor=abc" {
+			t.Errorf("wanted tracestate to be propagated got %q", sc.TraceState)
+		}
+		if b := ParseBaggage(h.Get(HeaderBaggage)); b["user"] != "a b" || b["tenant"] != "t1" {
+			t.Errorf("unexpected baggage %q", h.Get(HeaderBaggage))
+		}
+	}
+	if len(ids) != 2 {
+		t.Errorf("wanted unique span id per attempt got %v", ids)
+	}
+	if len(rec.start) != 2 || len(rec.end) != 2 || rec.end[0].Parent != parent.SpanID ||
+		rec.end[0].StatusCode != 200 {
+		t.Errorf("unexpected exported spans %+v", rec.end)
+	}
+}
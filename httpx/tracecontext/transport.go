@@ -0,0 +1,117 @@
+package tracecontext
+
+import (
+	"net/http"
+	"time"
+)
+
+// Span is single outgoing request attempt reported to [Exporter].
+type Span struct {
+	Name        string
+	SpanContext SpanContext
+	// Parent is span id of parent span, it's invalid for root spans
+	Parent     SpanID
+	Method     string
+	URL        string
+	Start      time.Time
+	End        time.Time
+	StatusCode int
+	Err        error
+}
+
+// Exporter receives span start and end events, implementation must be safe for concurrent use.
+type Exporter interface {
+	SpanStart(Span)
+	SpanEnd(Span)
+}
+
+// ExporterFunc adapts function to [Exporter] which only receives span end events.
+type ExporterFunc func(Span)
+
+func (f ExporterFunc) SpanStart(Span) {}
+
+func (f ExporterFunc) SpanEnd(s Span) { f(s) }
+
+// Transport is [net/http.RoundTripper] which creates child span for every attempt
+// and injects traceparent, tracestate and baggage headers.
+//
+// Parent span is taken from request context, see [ContextWithSpanContext]. If request
+// context doesn't carry span context, new trace is started for every attempt.
+//
+//	c := httpx.New(false).SetTransport(&tracecontext.Transport{
+//		Base:     httpx.GetDefaultTransport(),
+//		Exporter: exporter,
+//	})
+type Transport struct {
+	// Base is the transport used for sending request, [net/http.DefaultTransport] if nil
+	Base http.RoundTripper
+	// Exporter receives span of every attempt, spans are not exported if nil
+	Exporter Exporter
+	// SpanName returns name of span, default is "HTTP {method}"
+	SpanName func(*http.Request) string
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	parent, ok := SpanContextFromContext(req.Context())
+	sc := parent.Child()
+	if !ok {
+		sc = NewRoot()
+	}
+
+	// round tripper must not modify the provided request
+	r2 := req.Clone(req.Context())
+	Inject(r2, sc)
+
+	if t.Exporter == nil {
+		return base.RoundTrip(r2)
+	}
+
+	name := "HTTP " + req.Method
+	if t.SpanName != nil {
+		name = t.SpanName(req)
+	}
+	span := Span{
+		Name:        name,
+		SpanContext: sc,
+		Parent:      parent.SpanID,
+		Method:      req.Method,
+		URL:         req.URL.String(),
+		Start:       time.Now(),
+	}
+	t.Exporter.SpanStart(span)
+
+	res, err := base.RoundTrip(r2)
+	span.End = time.Now()
+	span.Err = err
+	if res != nil {
+		span.StatusCode = res.StatusCode
+	}
+	t.Exporter.SpanEnd(span)
+	return res, err
+}
+
+// CloseIdleConnections closes idle connections of base transport if supported.
+func (t *Transport) CloseIdleConnections() {
+	type closeIdler interface{ CloseIdleConnections() }
+	if ci, ok := t.Base.(closeIdler); ok {
+		ci.CloseIdleConnections()
+	}
+}
+
+// Hook is request hook which injects single child span for all the attempts of request.
+// It only propagates headers, use [Hooks] for exporting the span or [Transport] for span
+// of every attempt.
+func Hook(req *http.Request) error {
+	parent, ok := SpanContextFromContext(req.Context())
+	sc := parent.Child()
+	if !ok {
+		sc = NewRoot()
+	}
+	Inject(req, sc)
+	return nil
+}
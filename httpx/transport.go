@@ -3,9 +3,13 @@ package httpx
 import (
 	"context"
 	"crypto/tls"
+	"io"
 	"net"
 	"net/http"
+	"net/http/httptrace"
 	"net/url"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -51,6 +55,144 @@ func GetDefaultTransport() *http.Transport {
 	return defaultTransport.Clone()
 }
 
+// execState is the state of single Exec call shared with [clientTransport]
+// through request context.
+type execState struct {
+	start     time.Time
+	name      string
+	attempt   atomic.Int32
+	timings   *Timings
+	redirects *RedirectHistory
+	// maxBodySize limits body of every attempt so retry hooks read limited bodies
+	maxBodySize int64
+}
+
+type execStateKey struct{}
+
+func withExecState(ctx context.Context, st *execState) context.Context {
+	return context.WithValue(ctx, execStateKey{}, st)
+}
+
+func execStateFrom(ctx context.Context) *execState {
+	st, _ := ctx.Value(execStateKey{}).(*execState)
+	return st
+}
+
+// AttemptFromContext returns attempt number of request being sent by [Client], it can be used
+// from custom transports and hooks. Zero is returned for requests not sent through [Client].
+func AttemptFromContext(ctx context.Context) int {
+	if st := execStateFrom(ctx); st != nil {
+		return int(st.attempt.Load())
+	}
+	return 0
+}
+
+// clientTransport sits between [net/http.Client] and configured transport
+// so client level policies are applied to every attempt.
+type clientTransport struct {
+	c *Client
+}
+
+func (t *clientTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	c := t.c
+	st := execStateFrom(req.Context())
+	if st == nil {
+		st = &execState{}
+	}
+	// redirected requests carry the response which caused redirect
+	// and share the attempt number with it
+	attempt := int(st.attempt.Load())
+	if req.Response == nil {
+		attempt = int(st.attempt.Add(1))
+	}
+
+	// done funcs are called once the response body is consumed or round trip failed
+	var done []func()
+	finish := func() {
+		for _, f := range done {
+			f()
+		}
+	}
+
+	var gotResponse []func(*http.Response, error)
+	if c.metrics != nil {
+		var record func(*http.Response, error)
+		var recordDone func()
+		req, record, recordDone = c.metrics.begin(req, attempt > 1 && req.Response == nil)
+		gotResponse = append(gotResponse, record)
+		done = append(done, recordDone)
+	}
+
+	if c.bulkhead != nil {
+		release, err := c.bulkhead.Acquire(req.Context(), req.URL.Host)
+		if err != nil {
+			for _, f := range gotResponse {
+				f(nil, err)
+			}
+			finish()
+			return nil, err
+		}
+		done = append(done, release)
+	}
+
+	var rec *timingRecorder
+	if st.timings != nil {
+		rec = st.timings.begin(req, attempt)
+		req = req.WithContext(httptrace.WithClientTrace(req.Context(), rec.clientTrace()))
+	}
+
+	start := time.Now()
+	res, err := c.transport.RoundTrip(req)
+	if st.redirects != nil {
+		st.redirects.record(req, attempt, start, res, err)
+	}
+	if rec != nil {
+		rec.gotResponse(err)
+		done = append(done, rec.bodyDone)
+	}
+	for _, f := range gotResponse {
+		f(res, err)
+	}
+	if err != nil || res.Body == nil {
+		finish()
+		return res, err
+	}
+	limitBody(res, st.maxBodySize)
+	if len(done) > 0 {
+		res.Body = &doneBody{ReadCloser: res.Body, done: finish}
+	}
+	return res, nil
+}
+
+// doneBody calls done once the response body is read till EOF, failed or closed.
+type doneBody struct {
+	io.ReadCloser
+	once sync.Once
+	done func()
+}
+
+func (b *doneBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil {
+		b.once.Do(b.done)
+	}
+	return n, err
+}
+
+func (b *doneBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.done)
+	return err
+}
+
+// CloseIdleConnections closes idle connections of configured transport if supported.
+func (t *clientTransport) CloseIdleConnections() {
+	type closeIdler interface{ CloseIdleConnections() }
+	if ci, ok := t.c.transport.(closeIdler); ok {
+		ci.CloseIdleConnections()
+	}
+}
+
 // transportDailContext return DailContext Func for setting it in transport.
 // usable for field such as DialContext and DialTLSContext.
 func transportDailContext() func(context.Context, string, string) (net.Conn, error) {
@@ -0,0 +1,141 @@
+package vcr
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+	"unicode/utf8"
+)
+
+const cassetteVersion = 1
+
+// Cassette is the set of recorded interactions stored in single JSON file.
+type Cassette struct {
+	Version      int            `json:"version"`
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// Interaction is single recorded request and its response.
+type Interaction struct {
+	Request    Request   `json:"request"`
+	Response   Response  `json:"response"`
+	RecordedAt time.Time `json:"recorded_at"`
+	Duration   string    `json:"duration,omitempty"`
+
+	replayed bool
+}
+
+type Request struct {
+	Method  string      `json:"method"`
+	URL     string      `json:"url"`
+	Headers http.Header `json:"headers,omitempty"`
+	Body    Body        `json:"body"`
+}
+
+type Response struct {
+	Status     string      `json:"status"`
+	StatusCode int         `json:"status_code"`
+	Proto      string      `json:"proto"`
+	Headers    http.Header `json:"headers,omitempty"`
+	Body       Body        `json:"body"`
+}
+
+// Body is recorded body, non UTF-8 bodies are stored base64 encoded.
+type Body []byte
+
+type jsonBody struct {
+	Encoding string `json:"encoding,omitempty"`
+	Data     string `json:"data"`
+}
+
+func (b Body) MarshalJSON() ([]byte, error) {
+	if utf8.Valid(b) {
+		return json.Marshal(jsonBody{Data: string(b)})
+	}
+	return json.Marshal(jsonBody{Encoding: "base64", Data: base64.StdEncoding.EncodeToString(b)})
+}
+
+func (b *Body) UnmarshalJSON(data []byte) error {
+	var jb jsonBody
+	if err := json.Unmarshal(data, &jb); err != nil {
+		return err
+	}
+	if jb.Encoding == "base64" {
+		d, err := base64.StdEncoding.DecodeString(jb.Data)
+		if err != nil {
+			return err
+		}
+		*b = d
+		return nil
+	}
+	*b = Body(jb.Data)
+	return nil
+}
+
+// Load reads cassette from path, missing file is returned as empty cassette
+// with [io/fs.ErrNotExist] error.
+func Load(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return &Cassette{Version: cassetteVersion}, err
+		}
+		return nil, err
+	}
+	c := &Cassette{}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Save atomically writes cassette to path creating the parent directories.
+func (c *Cassette) Save(path string) error {
+	c.Version = cassetteVersion
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, ".cassette-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// response returns new [net/http.Response] of recorded response for request.
+func (r Response) response(req *http.Request) *http.Response {
+	res := &http.Response{
+		Status:        r.Status,
+		StatusCode:    r.StatusCode,
+		Proto:         r.Proto,
+		Header:        r.Headers.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(r.Body)),
+		ContentLength: int64(len(r.Body)),
+		Request:       req,
+	}
+	if res.Header == nil {
+		res.Header = make(http.Header)
+	}
+	res.ProtoMajor, res.ProtoMinor, _ = http.ParseHTTPVersion(r.Proto)
+	return res
+}
@@ -0,0 +1,3 @@
+// Package vcr contains record and replay transport which records http interactions into cassette
+// files and replays them offline for deterministic tests
+package vcr
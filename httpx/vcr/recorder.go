@@ -0,0 +1,319 @@
+package vcr
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Mode is the recording mode of [Recorder].
+type Mode int
+
+const (
+	// ModeOnce records interactions if cassette doesn't exist otherwise only replays them,
+	// requests without recorded interaction fail with [ErrInteractionNotFound].
+	ModeOnce Mode = iota
+	// ModeNewEpisodes replays recorded interactions and records the new ones.
+	ModeNewEpisodes
+	// ModeNone only replays recorded interactions and never touches the network.
+	ModeNone
+	// ModeAll always performs real requests and re-records the whole cassette.
+	ModeAll
+)
+
+// ErrInteractionNotFound is returned when request has no matching recorded interaction
+// and recorder is not allowed to record it.
+var ErrInteractionNotFound = errors.New("vcr: interaction not found")
+
+const redacted = "[REDACTED]"
+
+// Matcher reports whether request with its body matches recorded request.
+type Matcher func(req *http.Request, body []byte, rec Request) bool
+
+// MatchMethod matches the request method.
+func MatchMethod(req *http.Request, _ []byte, rec Request) bool {
+	return req.Method == rec.Method
+}
+
+// MatchURL matches the full request url, query parameters order is ignored.
+func MatchURL(req *http.Request, _ []byte, rec Request) bool {
+	u, err := url.Parse(rec.URL)
+	if err != nil {
+		return false
+	}
+	a, b := *req.URL, *u
+	a.RawQuery, b.RawQuery = a.Query().Encode(), b.Query().Encode()
+	return a.String() == b.String()
+}
+
+// MatchBody matches the request body byte by byte.
+func MatchBody(_ *http.Request, body []byte, rec Request) bool {
+	return bytes.Equal(body, rec.Body)
+}
+
+// MatchHeaders returns matcher which matches values of provided headers.
+func MatchHeaders(keys ...string) Matcher {
+	return func(req *http.Request, _ []byte, rec Request) bool {
+		for _, k := range keys {
+			a, b := req.Header.Values(k), rec.Headers.Values(k)
+			if len(a) != len(b) {
+				return false
+			}
+			for i := range a {
+				if a[i] != b[i] {
+					return false
+				}
+			}
+		}
+		return true
+	}
+}
+
+// Options configures the [Recorder].
+type Options struct {
+	Mode Mode
+	// Matchers used for finding recorded interaction of request, all of them must match.
+	// By default [MatchMethod] and [MatchURL] are used.
+	Matchers []Matcher
+	// RedactHeaders are request and response headers whose values are replaced before
+	// interaction is stored. Authorization, Proxy-Authorization, Cookie and Set-Cookie
+	// are always redacted.
+	RedactHeaders []string
+	// RedactQuery are the query parameters whose values are replaced in recorded url.
+	RedactQuery []string
+	// Redact is called with every new interaction before it is stored
+	// and can be used for removing secrets from bodies.
+	Redact func(*Interaction)
+	// Base is the transport used for real requests, [net/http.DefaultTransport] if nil
+	Base http.RoundTripper
+}
+
+// Recorder is [net/http.RoundTripper] which records interactions into cassette file
+// and replays them. Call [Recorder.Stop] once done for saving recorded interactions.
+//
+//	rec, err := vcr.New("testdata/users.json", vcr.Options{Mode: vcr.ModeOnce})
+//	defer rec.Stop()
+//	c := httpx.New(false).SetTransport(rec)
+//
+// Redaction is applied before interaction is stored and to the request being replayed,
+// so matchers compare redacted headers and query parameters. [Options.Redact] is not
+// applied to replayed requests.
+type Recorder struct {
+	mu       sync.Mutex
+	path     string
+	opts     Options
+	cassette *Cassette
+	record   bool
+	replay   bool
+	dirty    bool
+}
+
+// New returns recorder for cassette at path.
+func New(path string, opts Options) (*Recorder, error) {
+	c, err := Load(path)
+	missing := errors.Is(err, fs.ErrNotExist)
+	if err != nil && !missing {
+		return nil, fmt.Errorf("failed to load cassette: %w", err)
+	}
+	if len(opts.Matchers) == 0 {
+		opts.Matchers = []Matcher{MatchMethod, MatchURL}
+	}
+	if opts.Base == nil {
+		opts.Base = http.DefaultTransport
+	}
+
+	r := &Recorder{path: path, opts: opts, cassette: c}
+	switch opts.Mode {
+	case ModeOnce:
+		r.record, r.replay = missing, !missing
+	case ModeNewEpisodes:
+		r.record, r.replay = true, true
+	case ModeNone:
+		r.replay = true
+	case ModeAll:
+		r.record = true
+		r.cassette = &Cassette{Version: cassetteVersion}
+	default:
+		return nil, fmt.Errorf("vcr: unknown mode %d", opts.Mode)
+	}
+	return r, nil
+}
+
+// Interactions returns the interactions of cassette.
+func (r *Recorder) Interactions() []*Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*Interaction(nil), r.cassette.Interactions...)
+}
+
+// Stop saves cassette if new interactions were recorded.
+func (r *Recorder) Stop() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.dirty {
+		return nil
+	}
+	if err := r.cassette.Save(r.path); err != nil {
+		return err
+	}
+	r.dirty = false
+	return nil
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.replay {
+		if i := r.find(r.redacted(req), body); i != nil {
+			return i.Response.response(req), nil
+		}
+	}
+	if !r.record {
+		return nil, fmt.Errorf("%w: %s %s", ErrInteractionNotFound, req.Method, req.URL)
+	}
+
+	r2 := req.Clone(req.Context())
+	if req.Body != nil && req.Body != http.NoBody {
+		r2.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	start := time.Now()
+	res, err := r.opts.Base.RoundTrip(r2)
+	if err != nil {
+		return nil, err
+	}
+	resBody, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = io.NopCloser(bytes.NewReader(resBody))
+
+	i := &Interaction{
+		Request: Request{
+			Method:  req.Method,
+			URL:     req.URL.String(),
+			Headers: req.Header.Clone(),
+			Body:    body,
+		},
+		Response: Response{
+			Status:     res.Status,
+			StatusCode: res.StatusCode,
+			Proto:      res.Proto,
+			Headers:    res.Header.Clone(),
+			Body:       resBody,
+		},
+		RecordedAt: start.UTC(),
+		Duration:   time.Since(start).String(),
+		replayed:   true,
+	}
+	r.redact(i)
+
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, i)
+	r.dirty = true
+	r.mu.Unlock()
+	return res, nil
+}
+
+// find returns first not yet replayed matching interaction,
+// if all matching interactions are replayed the first one is reused.
+func (r *Recorder) find(req *http.Request, body []byte) *Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var first *Interaction
+	for _, i := range r.cassette.Interactions {
+		if !r.match(req, body, i.Request) {
+			continue
+		}
+		if !i.replayed {
+			i.replayed = true
+			return i
+		}
+		if first == nil {
+			first = i
+		}
+	}
+	return first
+}
+
+func (r *Recorder) match(req *http.Request, body []byte, rec Request) bool {
+	for _, m := range r.opts.Matchers {
+		if !m(req, body, rec) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *Recorder) redact(i *Interaction) {
+	r.redactHeader(i.Request.Headers)
+	r.redactHeader(i.Response.Headers)
+	if len(r.opts.RedactQuery) > 0 {
+		if u, err := url.Parse(i.Request.URL); err == nil {
+			r.redactQuery(u)
+			i.Request.URL = u.String()
+		}
+	}
+	if r.opts.Redact != nil {
+		r.opts.Redact(i)
+	}
+}
+
+// redacted returns copy of request with redacted headers and query parameters
+// so it can be matched against recorded requests
+func (r *Recorder) redacted(req *http.Request) *http.Request {
+	r2 := req.Clone(req.Context())
+	r.redactHeader(r2.Header)
+	r.redactQuery(r2.URL)
+	return r2
+}
+
+func (r *Recorder) redactHeader(hdr http.Header) {
+	headers := []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+	headers = append(headers, r.opts.RedactHeaders...)
+	for _, h := range headers {
+		if vs := hdr.Values(h); len(vs) > 0 {
+			hdr[http.CanonicalHeaderKey(h)] = []string{redacted}
+		}
+	}
+}
+
+func (r *Recorder) redactQuery(u *url.URL) {
+	if len(r.opts.RedactQuery) == 0 {
+		return
+	}
+	q := u.Query()
+	for _, k := range r.opts.RedactQuery {
+		if q.Has(k) {
+			q.Set(k, redacted)
+		}
+	}
+	u.RawQuery = q.Encode()
+}
+
+// readRequestBody reads request body using GetBody when available so request can be replayed.
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	// round tripper must close the request body
+	defer req.Body.Close()
+	rc := req.Body
+	if req.GetBody != nil {
+		var err error
+		if rc, err = req.GetBody(); err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+	}
+	return io.ReadAll(rc)
+}
@@ -0,0 +1,96 @@
+package vcr
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"collections/httpx"
+)
+
+// TestRecorder records interactions once and replays them without server
+func TestRecorder(t *testing.T) {
+	var hits int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Set-Cookie", "session=secret")
+		io.WriteString(w, r.Method+":"+r.URL.Query().Get("q")+":"+string(body))
+	}))
+	t.Cleanup(ts.Close)
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	exec := func(rec *Recorder, q, body string) (string, error) {
+		ho := httpx.NewHTTPOptions().Query("q", q).Header("Authorization", "Bearer token")
+		res, err := httpx.New(false).SetTransport(rec).Post(context.Background(), ts.URL, strings.NewReader(body), ho)
+		if err != nil {
+			return "", err
+		}
+		defer res.Body.Close()
+		b, err := io.ReadAll(res.Body)
+		return string(b), err
+	}
+
+	rec, err := New(path, Options{
+		Matchers:    []Matcher{MatchMethod, MatchURL, MatchBody},
+		RedactQuery: []string{"q"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := exec(rec, "1", "a")
+	if err != nil || got != "POST:1:a" {
+		t.Fatalf("wanted recorded response got %q, %v", got, err)
+	}
+	if err := rec.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"Bearer token", "session=secret", "q=1"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("cassette must not contain %q", secret)
+		}
+	}
+
+	// replay with server stopped hits, redacted values match any value
+	rec, err = New(path, Options{
+		Mode:        ModeOnce,
+		Matchers:    []Matcher{MatchMethod, MatchURL, MatchBody, MatchHeaders("Authorization")},
+		RedactQuery: []string{"q"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err = exec(rec, "2", "a")
+	if err != nil || got != "POST:1:a" || hits != 1 {
+		t.Fatalf("wanted replayed response got %q, %v, hits=%d", got, err, hits)
+	}
+	if _, err = exec(rec, "1", "b"); !errors.Is(err, ErrInteractionNotFound) {
+		t.Fatalf("wanted interaction not found got %v", err)
+	}
+
+	// new episodes records only unmatched interaction
+	rec, err = New(path, Options{Mode: ModeNewEpisodes, Matchers: []Matcher{MatchBody}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = exec(rec, "1", "a"); err != nil {
+		t.Fatal(err)
+	}
+	if got, err = exec(rec, "3", "b"); err != nil || got != "POST:3:b" {
+		t.Fatalf("wanted new recorded response got %q, %v", got, err)
+	}
+	if hits != 2 || len(rec.Interactions()) != 2 {
+		t.Fatalf("wanted 2 hits and interactions got %d and %d", hits, len(rec.Interactions()))
+	}
+}
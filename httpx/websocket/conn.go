@@ -0,0 +1,481 @@
+package websocket
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+)
+
+// closeTimeout is the most time waited for close frame of peer after sending ours
+const closeTimeout = 5 * time.Second
+
+// MessageType is type of data message.
+type MessageType int
+
+const (
+	TextMessage   MessageType = MessageType(opText)
+	BinaryMessage MessageType = MessageType(opBinary)
+)
+
+// Close codes defined by RFC 6455.
+const (
+	CloseNormalClosure    = 1000
+	CloseGoingAway        = 1001
+	CloseProtocolError    = 1002
+	CloseUnsupportedData  = 1003
+	CloseNoStatusReceived = 1005
+	CloseInvalidPayload   = 1007
+	ClosePolicyViolation  = 1008
+	CloseMessageTooBig    = 1009
+	CloseMandatoryExt     = 1010
+	CloseInternalError    = 1011
+)
+
+var (
+	// ErrProtocol is wrapped by errors of frames violating the protocol.
+	ErrProtocol = errors.New("websocket: protocol error")
+	// ErrMessageTooLarge is returned when received message exceeds the max message size.
+	ErrMessageTooLarge = errors.New("websocket: message too large")
+	// ErrPongTimeout is returned when peer didn't respond to keepalive ping in time.
+	ErrPongTimeout = errors.New("websocket: pong timeout")
+	// ErrClosed is returned by operations on closed connection.
+	ErrClosed = errors.New("websocket: connection closed")
+)
+
+// CloseError is returned by reads once peer closed the connection with close frame.
+type CloseError struct {
+	Code   int
+	Reason string
+}
+
+func (e CloseError) Error() string {
+	return fmt.Sprintf("websocket: closed code=%d, reason=%s", e.Code, e.Reason)
+}
+
+// Conn is websocket connection. Reads and writes may run concurrently, though only one
+// reader and message writer at a time is served. Pings of peer are answered and pongs are
+// observed only while connection is being read.
+type Conn struct {
+	rwc         io.ReadWriteCloser
+	br          *bufio.Reader
+	client      bool
+	subprotocol string
+	cfg         config
+
+	readMu sync.Mutex
+	// msgMu serializes data messages, wmu serializes frames so control frames can be sent
+	// between fragments
+	msgMu sync.Mutex
+	wmu   sync.Mutex
+	wbuf  []byte
+	fw    *flate.Writer
+
+	// lastSeen is unix nano time of last frame received
+	lastSeen atomic.Int64
+
+	mu            sync.Mutex
+	err           error
+	closeSent     bool
+	closeOnce     sync.Once
+	closeReceived chan struct{}
+	recvOnce      sync.Once
+	done          chan struct{}
+}
+
+// config is the negotiated connection settings
+type config struct {
+	maxMessageSize       int64
+	fragmentSize         int
+	compress             bool
+	compressionLevel     int
+	compressionThreshold int
+}
+
+func newConn(rwc io.ReadWriteCloser, br *bufio.Reader, client bool, cfg config) *Conn {
+	if br == nil {
+		br = bufio.NewReader(rwc)
+	}
+	c := &Conn{
+		rwc:           rwc,
+		br:            br,
+		client:        client,
+		cfg:           cfg,
+		closeReceived: make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+	c.lastSeen.Store(time.Now().UnixNano())
+	return c
+}
+
+// Subprotocol returns the subprotocol selected by server, empty if none.
+func (c *Conn) Subprotocol() string {
+	return c.subprotocol
+}
+
+// Done is closed once the connection is closed.
+func (c *Conn) Done() <-chan struct{} {
+	return c.done
+}
+
+// ReadMessage reads next data message, fragmented and compressed messages are assembled.
+// Connection is closed when ctx is done before message is read. Once peer closes the
+// connection [CloseError] is returned.
+func (c *Conn) ReadMessage(ctx context.Context) (MessageType, []byte, error) {
+	c.readMu.Lock()
+	defer c.readMu.Unlock()
+	if err := c.closedErr(); err != nil {
+		return 0, nil, err
+	}
+	stop := context.AfterFunc(ctx, func() { c.fail(context.Cause(ctx)) })
+	defer stop()
+
+	typ, msg, err := c.readMessage()
+	if err != nil {
+		return 0, nil, c.wrapErr(err)
+	}
+	return typ, msg, nil
+}
+
+func (c *Conn) readMessage() (MessageType, []byte, error) {
+	var (
+		msg        bytes.Buffer
+		typ        opcode
+		compressed bool
+		inMessage  bool
+	)
+	for {
+		h, err := readFrameHeader(c.br)
+		if err != nil {
+			return 0, nil, c.abort(CloseProtocolError, err)
+		}
+		if h.masked == c.client {
+			return 0, nil, c.abort(CloseProtocolError, fmt.Errorf("%w: invalid frame masking", ErrProtocol))
+		}
+		c.lastSeen.Store(time.Now().UnixNano())
+
+		if h.opcode.control() {
+			payload := make([]byte, h.length)
+			if _, err := io.ReadFull(c.br, payload); err != nil {
+				return 0, nil, c.abort(CloseProtocolError, err)
+			}
+			if h.masked {
+				maskBytes(h.mask, 0, payload)
+			}
+			if err := c.handleControl(h.opcode, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		}
+
+		switch {
+		case h.opcode == opContinuation && !inMessage:
+			return 0, nil, c.abort(CloseProtocolError, fmt.Errorf("%w: unexpected continuation frame", ErrProtocol))
+		case h.opcode != opContinuation && inMessage:
+			return 0, nil, c.abort(CloseProtocolError, fmt.Errorf("%w: expected continuation frame", ErrProtocol))
+		case h.rsv1 && (!c.cfg.compress || h.opcode == opContinuation):
+			return 0, nil, c.abort(CloseProtocolError, fmt.Errorf("%w: unexpected compressed frame", ErrProtocol))
+		}
+		if h.opcode != opContinuation {
+			typ, compressed, inMessage = h.opcode, h.rsv1, true
+		}
+
+		if limit := c.cfg.maxMessageSize; limit > 0 && int64(msg.Len())+h.length > limit {
+			return 0, nil, c.abort(CloseMessageTooBig, ErrMessageTooLarge)
+		}
+		start := msg.Len()
+		if _, err := io.CopyN(&msg, c.br, h.length); err != nil {
+			return 0, nil, c.abort(CloseProtocolError, err)
+		}
+		if h.masked {
+			maskBytes(h.mask, 0, msg.Bytes()[start:])
+		}
+		if h.fin {
+			break
+		}
+	}
+
+	data := msg.Bytes()
+	if compressed {
+		var err error
+		if data, err = decompress(data, c.cfg.maxMessageSize); err != nil {
+			if errors.Is(err, ErrMessageTooLarge) {
+				return 0, nil, c.abort(CloseMessageTooBig, err)
+			}
+			return 0, nil, c.abort(CloseInvalidPayload, fmt.Errorf("%w: %w", ErrProtocol, err))
+		}
+	}
+	if typ == opText && !utf8.Valid(data) {
+		return 0, nil, c.abort(CloseInvalidPayload, fmt.Errorf("%w: invalid utf-8 text", ErrProtocol))
+	}
+	return MessageType(typ), data, nil
+}
+
+func (c *Conn) handleControl(op opcode, payload []byte) error {
+	switch op {
+	case opPing:
+		c.mu.Lock()
+		closing := c.closeSent
+		c.mu.Unlock()
+		if !closing {
+			return c.writeFrame(opPong, true, false, payload)
+		}
+	case opClose:
+		code, reason, err := parseClose(payload)
+		if err != nil {
+			return c.abort(CloseProtocolError, err)
+		}
+		c.mu.Lock()
+		echo := !c.closeSent
+		c.closeSent = true
+		c.mu.Unlock()
+		if echo {
+			// peer initiated close, it's echoed with the same code
+			echoCode := code
+			if code == CloseNoStatusReceived {
+				echoCode = 0
+			}
+			_ = c.writeFrame(opClose, true, false, closePayload(echoCode, ""))
+		}
+		c.recvOnce.Do(func() { close(c.closeReceived) })
+		// connection closed by us reports ErrClosed rather than echo of peer
+		var closeErr error = ErrClosed
+		if echo {
+			closeErr = CloseError{Code: code, Reason: reason}
+		}
+		c.fail(closeErr)
+		return closeErr
+	}
+	return nil
+}
+
+// WriteMessage sends data message, it's split into frames of configured fragment size and
+// compressed when permessage-deflate was negotiated. Connection is closed when ctx is done
+// before message is written.
+func (c *Conn) WriteMessage(ctx context.Context, typ MessageType, data []byte) error {
+	if typ != TextMessage && typ != BinaryMessage {
+		return fmt.Errorf("websocket: invalid message type=%d", typ)
+	}
+	c.msgMu.Lock()
+	defer c.msgMu.Unlock()
+	if err := c.closedErr(); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	closing := c.closeSent
+	c.mu.Unlock()
+	if closing {
+		return ErrClosed
+	}
+	stop := context.AfterFunc(ctx, func() { c.fail(context.Cause(ctx)) })
+	defer stop()
+
+	payload, rsv1 := data, false
+	if c.cfg.compress && len(data) >= c.cfg.compressionThreshold {
+		var err error
+		if payload, err = c.compress(data); err != nil {
+			return err
+		}
+		rsv1 = true
+	}
+
+	size := c.cfg.fragmentSize
+	if size <= 0 {
+		size = len(payload)
+	}
+	op := opcode(typ)
+	for {
+		n := min(size, len(payload))
+		fin := n == len(payload)
+		if err := c.writeFrame(op, fin, rsv1, payload[:n]); err != nil {
+			return c.wrapErr(err)
+		}
+		if fin {
+			return nil
+		}
+		payload = payload[n:]
+		op, rsv1 = opContinuation, false
+	}
+}
+
+// compress deflates message without context takeover, flate writer is reused between messages
+func (c *Conn) compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	if c.fw == nil {
+		fw, err := flate.NewWriter(&buf, c.cfg.compressionLevel)
+		if err != nil {
+			return nil, err
+		}
+		c.fw = fw
+	} else {
+		c.fw.Reset(&buf)
+	}
+	if _, err := c.fw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := c.fw.Flush(); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), deflateTail), nil
+}
+
+func (c *Conn) writeFrame(op opcode, fin, rsv1 bool, payload []byte) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	if err := c.closedErr(); err != nil {
+		return err
+	}
+	c.wbuf = appendFrame(c.wbuf[:0], op, fin, rsv1, c.client, payload)
+	if _, err := c.rwc.Write(c.wbuf); err != nil {
+		c.fail(err)
+		return c.wrapErr(err)
+	}
+	return nil
+}
+
+// Close performs close handshake with code and reason, it waits for close frame of peer
+// before closing the connection. Connection closed by peer returns nil.
+func (c *Conn) Close(code int, reason string) error {
+	c.mu.Lock()
+	closing := c.closeSent
+	c.closeSent = true
+	c.mu.Unlock()
+	if closing {
+		<-c.done
+		return nil
+	}
+
+	if err := c.writeFrame(opClose, true, false, closePayload(code, reason)); err != nil {
+		c.fail(ErrClosed)
+		return err
+	}
+	timer := time.AfterFunc(closeTimeout, func() { c.fail(ErrClosed) })
+	defer timer.Stop()
+	// read the close frame of peer unless other goroutine reads it
+	if c.readMu.TryLock() {
+		for {
+			if _, _, err := c.readMessage(); err != nil {
+				break
+			}
+		}
+		c.readMu.Unlock()
+	}
+	select {
+	case <-c.closeReceived:
+	case <-c.done:
+	}
+	c.fail(ErrClosed)
+	return nil
+}
+
+// keepalive pings peer every interval and closes connection when no frame is received
+// within timeout after ping
+func (c *Conn) keepalive(interval, timeout time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-t.C:
+		}
+		sent := time.Now().UnixNano()
+		if err := c.writeFrame(opPing, true, false, nil); err != nil {
+			return
+		}
+		time.AfterFunc(timeout, func() {
+			if c.lastSeen.Load() < sent {
+				c.fail(ErrPongTimeout)
+			}
+		})
+	}
+}
+
+// abort sends close frame with code and closes the connection with err
+func (c *Conn) abort(code int, err error) error {
+	if c.closedErr() == nil {
+		c.mu.Lock()
+		send := !c.closeSent
+		c.closeSent = true
+		c.mu.Unlock()
+		if send {
+			timer := time.AfterFunc(closeTimeout, func() { c.fail(err) })
+			_ = c.writeFrame(opClose, true, false, closePayload(code, ""))
+			timer.Stop()
+		}
+	}
+	c.fail(err)
+	return c.wrapErr(err)
+}
+
+// fail closes the connection, err is returned by all subsequent operations
+func (c *Conn) fail(err error) {
+	c.closeOnce.Do(func() {
+		c.mu.Lock()
+		c.err = err
+		c.mu.Unlock()
+		close(c.done)
+		c.rwc.Close()
+	})
+}
+
+func (c *Conn) closedErr() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// wrapErr returns the error connection was closed with, io errors caused by closing are
+// replaced by it
+func (c *Conn) wrapErr(err error) error {
+	if cerr := c.closedErr(); cerr != nil {
+		return cerr
+	}
+	return err
+}
+
+func closePayload(code int, reason string) []byte {
+	if code == 0 {
+		return nil
+	}
+	if len(reason) > maxControlPayload-2 {
+		reason = reason[:maxControlPayload-2]
+	}
+	return append(binary.BigEndian.AppendUint16(nil, uint16(code)), reason...)
+}
+
+func parseClose(payload []byte) (int, string, error) {
+	switch {
+	case len(payload) == 0:
+		return CloseNoStatusReceived, "", nil
+	case len(payload) == 1:
+		return 0, "", fmt.Errorf("%w: invalid close payload", ErrProtocol)
+	}
+	code := int(binary.BigEndian.Uint16(payload))
+	reason := payload[2:]
+	if !validCloseCode(code) {
+		return 0, "", fmt.Errorf("%w: invalid close code=%d", ErrProtocol, code)
+	}
+	if !utf8.Valid(reason) {
+		return 0, "", fmt.Errorf("%w: invalid utf-8 close reason", ErrProtocol)
+	}
+	return code, string(reason), nil
+}
+
+func validCloseCode(code int) bool {
+	switch {
+	case code >= 1000 && code <= 1003, code >= 1007 && code <= 1011:
+		return true
+	case code >= 3000 && code <= 4999:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,73 @@
+package websocket
+
+import (
+	"bytes"
+	"compress/flate"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const extensionDeflate = "permessage-deflate"
+
+// deflateOffer asks both sides not to keep compression context between messages so every
+// message is compressed independently
+const deflateOffer = extensionDeflate + "; client_no_context_takeover; server_no_context_takeover"
+
+var (
+	// deflateTail is removed from compressed message by sender, RFC 7692 section 7.2.1
+	deflateTail = []byte{0x00, 0x00, 0xff, 0xff}
+	// finalBlock is empty final stored block which ends the inflated stream
+	finalBlock = []byte{0x01, 0x00, 0x00, 0xff, 0xff}
+)
+
+// decompress inflates the message, limit is the most bytes of inflated message
+func decompress(data []byte, limit int64) ([]byte, error) {
+	fr := flate.NewReader(io.MultiReader(bytes.NewReader(data), bytes.NewReader(deflateTail), bytes.NewReader(finalBlock)))
+	defer fr.Close()
+	if limit <= 0 {
+		return io.ReadAll(fr)
+	}
+	out, err := io.ReadAll(io.LimitReader(fr, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(out)) > limit {
+		return nil, ErrMessageTooLarge
+	}
+	return out, nil
+}
+
+// negotiateDeflate reports whether server accepted permessage-deflate offer
+func negotiateDeflate(h http.Header) (bool, error) {
+	accepted := false
+	for _, v := range h.Values("Sec-WebSocket-Extensions") {
+		for ext := range strings.SplitSeq(v, ",") {
+			params := strings.Split(ext, ";")
+			name := strings.TrimSpace(params[0])
+			if name == "" {
+				continue
+			}
+			if name != extensionDeflate || accepted {
+				return false, fmt.Errorf("websocket: unexpected extension %q", name)
+			}
+			noTakeover := false
+			for _, p := range params[1:] {
+				key, _, _ := strings.Cut(strings.TrimSpace(p), "=")
+				switch strings.TrimSpace(key) {
+				case "server_no_context_takeover":
+					noTakeover = true
+				case "client_no_context_takeover", "server_max_window_bits":
+				default:
+					return false, fmt.Errorf("websocket: unexpected %s parameter %q", extensionDeflate, key)
+				}
+			}
+			if !noTakeover {
+				return false, fmt.Errorf("websocket: server must not use context takeover")
+			}
+			accepted = true
+		}
+	}
+	return accepted, nil
+}
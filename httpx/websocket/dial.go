@@ -0,0 +1,223 @@
+package websocket
+
+import (
+	"bufio"
+	"compress/flate"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"collections/httpx"
+	"collections/httpx/hooks"
+)
+
+const (
+	defaultMaxMessageSize       = 32 << 20
+	defaultCompressionThreshold = 128
+	// acceptGUID is appended to key for computing Sec-WebSocket-Accept
+	acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+)
+
+// Options configures the [Dialer].
+type Options struct {
+	// HTTPOptions returns options of handshake request, it can be used for setting auth
+	// headers, queries and request hook. Response hooks are not used.
+	HTTPOptions func() *httpx.HTTPOptions
+	// Subprotocols offered to server, server selects one of them
+	Subprotocols []string
+	// MaxMessageSize limits size of received message after decompression, default is 32MB,
+	// negative disables the limit
+	MaxMessageSize int64
+	// FragmentSize splits sent messages into frames of this size, zero sends every message
+	// as single frame
+	FragmentSize int
+	// Compression offers permessage-deflate extension without context takeover
+	Compression bool
+	// CompressionLevel is the flate level, default is [compress/flate.DefaultCompression]
+	CompressionLevel int
+	// CompressionThreshold is the smallest message which is compressed, default is 128 bytes
+	CompressionThreshold int
+	// PingInterval enables keepalive pings, connection is closed with [ErrPongTimeout] when no
+	// frame is received within PongTimeout after ping, default PongTimeout is PingInterval.
+	// Pongs are only observed while connection is read, so it must be read continuously.
+	PingInterval time.Duration
+	PongTimeout  time.Duration
+
+	// Backoff is used for wait time between reconnects, default is equal jitter backoff
+	Backoff *hooks.BackoffWithJitter
+	// MaxReconnects limits consecutive failed dials of [Reconnector], zero is unlimited
+	MaxReconnects int
+	// OnConnect is called with every new connection of [Reconnector] e.g. for subscribing,
+	// failed call drops the connection and it's dialed again
+	OnConnect func(context.Context, *Conn) error
+}
+
+// HandshakeError is returned when server didn't upgrade the connection.
+type HandshakeError struct {
+	StatusCode int
+	Reason     string
+}
+
+func (e HandshakeError) Error() string {
+	return fmt.Sprintf("websocket: handshake failed status=%d, reason=%s", e.StatusCode, e.Reason)
+}
+
+// Dialer opens websocket connections through transport of httpx client so its dialer,
+// proxy and TLS config are used.
+type Dialer struct {
+	c    *httpx.Client
+	opts Options
+	// backoffMu guards backoff shared by reconnectors
+	backoffMu sync.Mutex
+}
+
+// New returns dialer of websocket connections through c.
+func New(c *httpx.Client, opts Options) *Dialer {
+	if opts.MaxMessageSize == 0 {
+		opts.MaxMessageSize = defaultMaxMessageSize
+	}
+	if opts.CompressionLevel == 0 {
+		opts.CompressionLevel = flate.DefaultCompression
+	}
+	if opts.CompressionThreshold <= 0 {
+		opts.CompressionThreshold = defaultCompressionThreshold
+	}
+	if opts.PongTimeout <= 0 {
+		opts.PongTimeout = opts.PingInterval
+	}
+	if opts.Backoff == nil {
+		opts.Backoff = hooks.NewBackoffWithJitter(0, 0, hooks.EqualJitter)
+	}
+	return &Dialer{c: c, opts: opts}
+}
+
+// Dial performs opening handshake with server at uri, ws and wss schemes are dialed as
+// http and https. Ctx is used for the handshake only.
+func (d *Dialer) Dial(ctx context.Context, uri string) (*Conn, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "ws":
+		u.Scheme = "http"
+	case "wss":
+		u.Scheme = "https"
+	case "http", "https":
+	default:
+		return nil, fmt.Errorf("websocket: unsupported scheme %q", u.Scheme)
+	}
+
+	var ho *httpx.HTTPOptions
+	if d.opts.HTTPOptions != nil {
+		ho = d.opts.HTTPOptions()
+	}
+	req, err := d.c.NewRequest(ctx, http.MethodGet, u.String(), nil, ho)
+	if err != nil {
+		return nil, err
+	}
+	var nonce [16]byte
+	_, _ = rand.Read(nonce[:])
+	key := base64.StdEncoding.EncodeToString(nonce[:])
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	if len(d.opts.Subprotocols) > 0 {
+		req.Header.Set("Sec-WebSocket-Protocol", strings.Join(d.opts.Subprotocols, ", "))
+	}
+	if d.opts.Compression {
+		req.Header.Set("Sec-WebSocket-Extensions", deflateOffer)
+	}
+
+	// client policies such as bulkhead would hold the connection for its whole life,
+	// so the handshake goes straight to transport
+	res, err := d.c.Transport().RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusSwitchingProtocols {
+		b, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		res.Body.Close()
+		return nil, HandshakeError{StatusCode: res.StatusCode, Reason: strings.TrimSpace(string(b))}
+	}
+	rwc, ok := res.Body.(io.ReadWriteCloser)
+	if !ok {
+		res.Body.Close()
+		return nil, fmt.Errorf("websocket: transport %T doesn't support protocol upgrade", d.c.Transport())
+	}
+
+	conn, err := d.handshake(res, rwc, key)
+	if err != nil {
+		rwc.Close()
+		return nil, err
+	}
+	if d.opts.PingInterval > 0 {
+		go conn.keepalive(d.opts.PingInterval, d.opts.PongTimeout)
+	}
+	return conn, nil
+}
+
+// handshake validates response of server and returns connection with negotiated settings
+func (d *Dialer) handshake(res *http.Response, rwc io.ReadWriteCloser, key string) (*Conn, error) {
+	fail := func(reason string) error {
+		return HandshakeError{StatusCode: res.StatusCode, Reason: reason}
+	}
+	if !strings.EqualFold(res.Header.Get("Upgrade"), "websocket") {
+		return nil, fail("missing upgrade header")
+	}
+	if !headerContains(res.Header, "Connection", "upgrade") {
+		return nil, fail("missing connection header")
+	}
+	sum := sha1.Sum([]byte(key + acceptGUID))
+	if res.Header.Get("Sec-WebSocket-Accept") != base64.StdEncoding.EncodeToString(sum[:]) {
+		return nil, fail("invalid accept key")
+	}
+	proto := res.Header.Get("Sec-WebSocket-Protocol")
+	if proto != "" && !slices.Contains(d.opts.Subprotocols, proto) {
+		return nil, fail(fmt.Sprintf("unexpected subprotocol %q", proto))
+	}
+	compress, err := negotiateDeflate(res.Header)
+	if err != nil {
+		return nil, err
+	}
+	if compress && !d.opts.Compression {
+		return nil, fail("unexpected extension")
+	}
+
+	conn := newConn(rwc, bufio.NewReader(rwc), true, config{
+		maxMessageSize:       d.opts.MaxMessageSize,
+		fragmentSize:         d.opts.FragmentSize,
+		compress:             compress,
+		compressionLevel:     d.opts.CompressionLevel,
+		compressionThreshold: d.opts.CompressionThreshold,
+	})
+	conn.subprotocol = proto
+	return conn, nil
+}
+
+func (d *Dialer) backoff(attempt int) time.Duration {
+	d.backoffMu.Lock()
+	defer d.backoffMu.Unlock()
+	return d.opts.Backoff.NextWaitDuration(nil, attempt)
+}
+
+func headerContains(h http.Header, key, token string) bool {
+	for _, v := range h.Values(key) {
+		for t := range strings.SplitSeq(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
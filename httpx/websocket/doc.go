@@ -0,0 +1,3 @@
+// Package websocket contains RFC 6455 client dialing through httpx client transport with
+// fragmentation, keepalive, permessage-deflate compression and reconnects
+package websocket
@@ -0,0 +1,138 @@
+package websocket
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+type opcode byte
+
+const (
+	opContinuation opcode = 0x0
+	opText         opcode = 0x1
+	opBinary       opcode = 0x2
+	opClose        opcode = 0x8
+	opPing         opcode = 0x9
+	opPong         opcode = 0xa
+)
+
+func (op opcode) control() bool {
+	return op&0x8 != 0
+}
+
+const (
+	finBit  = 0x80
+	rsv1Bit = 0x40
+	rsvBits = 0x70
+	maskBit = 0x80
+	// maxControlPayload is the most bytes of control frame payload
+	maxControlPayload = 125
+	maxHeaderSize     = 14
+)
+
+type frameHeader struct {
+	fin    bool
+	rsv1   bool
+	opcode opcode
+	length int64
+	masked bool
+	mask   [4]byte
+}
+
+// readFrameHeader reads and validates header of frame which doesn't depend on connection state
+func readFrameHeader(r io.Reader) (frameHeader, error) {
+	var h frameHeader
+	var b [8]byte
+	if _, err := io.ReadFull(r, b[:2]); err != nil {
+		return h, err
+	}
+	h.fin = b[0]&finBit != 0
+	h.rsv1 = b[0]&rsv1Bit != 0
+	h.opcode = opcode(b[0] & 0xf)
+	h.masked = b[1]&maskBit != 0
+	h.length = int64(b[1] & 0x7f)
+
+	if b[0]&(rsvBits&^rsv1Bit) != 0 {
+		return h, fmt.Errorf("%w: reserved bits set", ErrProtocol)
+	}
+	switch h.opcode {
+	case opContinuation, opText, opBinary, opClose, opPing, opPong:
+	default:
+		return h, fmt.Errorf("%w: unknown opcode=%d", ErrProtocol, h.opcode)
+	}
+
+	switch h.length {
+	case 126:
+		if _, err := io.ReadFull(r, b[:2]); err != nil {
+			return h, err
+		}
+		h.length = int64(binary.BigEndian.Uint16(b[:2]))
+	case 127:
+		if _, err := io.ReadFull(r, b[:8]); err != nil {
+			return h, err
+		}
+		n := binary.BigEndian.Uint64(b[:8])
+		if n>>63 != 0 {
+			return h, fmt.Errorf("%w: invalid payload length", ErrProtocol)
+		}
+		h.length = int64(n)
+	}
+
+	if h.opcode.control() && (!h.fin || h.length > maxControlPayload || h.rsv1) {
+		return h, fmt.Errorf("%w: invalid control frame", ErrProtocol)
+	}
+	if h.masked {
+		if _, err := io.ReadFull(r, h.mask[:]); err != nil {
+			return h, err
+		}
+	}
+	return h, nil
+}
+
+// appendFrame appends frame to buf, payload is masked when mask is set
+func appendFrame(buf []byte, op opcode, fin, rsv1, mask bool, payload []byte) []byte {
+	b0 := byte(op)
+	if fin {
+		b0 |= finBit
+	}
+	if rsv1 {
+		b0 |= rsv1Bit
+	}
+	var b1 byte
+	if mask {
+		b1 = maskBit
+	}
+
+	n := len(payload)
+	switch {
+	case n <= maxControlPayload:
+		buf = append(buf, b0, b1|byte(n))
+	case n <= 0xffff:
+		buf = append(buf, b0, b1|126)
+		buf = binary.BigEndian.AppendUint16(buf, uint16(n))
+	default:
+		buf = append(buf, b0, b1|127)
+		buf = binary.BigEndian.AppendUint64(buf, uint64(n))
+	}
+	if !mask {
+		return append(buf, payload...)
+	}
+
+	var key [4]byte
+	_, _ = rand.Read(key[:])
+	buf = append(buf, key[:]...)
+	start := len(buf)
+	buf = append(buf, payload...)
+	maskBytes(key, 0, buf[start:])
+	return buf
+}
+
+// maskBytes masks b in place starting at pos of the payload and returns the next position
+func maskBytes(key [4]byte, pos int, b []byte) int {
+	for i := range b {
+		b[i] ^= key[(pos+i)&3]
+	}
+	return (pos + len(b)) & 3
+}
@@ -0,0 +1,198 @@
+package websocket
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Reconnector keeps websocket connection to uri, lost connection is dialed again with
+// backoff. Messages sent by server while connection is down are lost.
+type Reconnector struct {
+	d   *Dialer
+	uri string
+	// ctx is cancelled by Close for aborting dials and backoff waits
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu     sync.Mutex
+	conn   *Conn
+	closed bool
+	// dialing is closed once the dial in progress ends
+	dialing chan struct{}
+}
+
+// Reconnector returns reconnecting connection to uri, it's dialed on first use.
+func (d *Dialer) Reconnector(uri string) *Reconnector {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Reconnector{d: d, uri: uri, ctx: ctx, cancel: cancel}
+}
+
+// ReadMessage reads next message and reconnects when connection is lost. Error is returned
+// when ctx is done, reconnects are exhausted or server closed connection with normal closure.
+func (r *Reconnector) ReadMessage(ctx context.Context) (MessageType, []byte, error) {
+	for {
+		conn, err := r.current(ctx)
+		if err != nil {
+			return 0, nil, err
+		}
+		typ, data, err := conn.ReadMessage(ctx)
+		if err == nil {
+			return typ, data, nil
+		}
+		var ce CloseError
+		if ctx.Err() != nil || errors.As(err, &ce) && ce.Code == CloseNormalClosure {
+			return 0, nil, err
+		}
+		r.drop(conn)
+	}
+}
+
+// WriteMessage writes message to current connection, connection is dialed when it's down.
+// Failed write drops the connection without retrying the message.
+func (r *Reconnector) WriteMessage(ctx context.Context, typ MessageType, data []byte) error {
+	conn, err := r.current(ctx)
+	if err != nil {
+		return err
+	}
+	if err := conn.WriteMessage(ctx, typ, data); err != nil {
+		r.drop(conn)
+		return err
+	}
+	return nil
+}
+
+// Close closes current connection with normal closure and stops reconnecting, dial in
+// progress is aborted with [ErrClosed].
+func (r *Reconnector) Close() error {
+	r.mu.Lock()
+	conn := r.conn
+	r.conn, r.closed = nil, true
+	r.mu.Unlock()
+	r.cancel()
+	if conn == nil {
+		return nil
+	}
+	return conn.Close(CloseNormalClosure, "")
+}
+
+// current returns the open connection or dials new one, lock is not held while dialing
+// so concurrent callers wait for the dial in progress.
+func (r *Reconnector) current(ctx context.Context) (*Conn, error) {
+	for {
+		r.mu.Lock()
+		if r.closed {
+			r.mu.Unlock()
+			return nil, ErrClosed
+		}
+		if r.conn != nil {
+			select {
+			case <-r.conn.Done():
+			default:
+				conn := r.conn
+				r.mu.Unlock()
+				return conn, nil
+			}
+		}
+		if dialing := r.dialing; dialing != nil {
+			r.mu.Unlock()
+			select {
+			case <-dialing:
+				continue
+			case <-ctx.Done():
+				return nil, context.Cause(ctx)
+			}
+		}
+		dialing := make(chan struct{})
+		r.dialing = dialing
+		r.mu.Unlock()
+
+		conn, err := r.dial(ctx)
+
+		r.mu.Lock()
+		r.dialing = nil
+		close(dialing)
+		if err == nil && r.closed {
+			err = ErrClosed
+		} else if err == nil {
+			r.conn = conn
+		}
+		r.mu.Unlock()
+		if err != nil {
+			if conn != nil {
+				conn.Close(CloseNormalClosure, "")
+			}
+			return nil, err
+		}
+		return conn, nil
+	}
+}
+
+// dial connects with backoff until ctx is done, reconnects are exhausted or reconnector
+// is closed
+func (r *Reconnector) dial(ctx context.Context) (*Conn, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stop := context.AfterFunc(r.ctx, cancel)
+	defer stop()
+
+	opts := r.d.opts
+	for attempt := 0; ; attempt++ {
+		conn, err := r.connect(ctx)
+		if err == nil {
+			return conn, nil
+		}
+		if r.ctx.Err() != nil {
+			return nil, ErrClosed
+		}
+		if ctx.Err() != nil || !temporary(err) || opts.MaxReconnects > 0 && attempt+1 >= opts.MaxReconnects {
+			return nil, err
+		}
+		timer := time.NewTimer(r.d.backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			if r.ctx.Err() != nil {
+				return nil, ErrClosed
+			}
+			return nil, context.Cause(ctx)
+		case <-timer.C:
+		}
+	}
+}
+
+func (r *Reconnector) connect(ctx context.Context) (*Conn, error) {
+	conn, err := r.d.Dial(ctx, r.uri)
+	if err != nil {
+		return nil, err
+	}
+	if r.d.opts.OnConnect != nil {
+		if err := r.d.opts.OnConnect(ctx, conn); err != nil {
+			conn.Close(CloseGoingAway, "")
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+// drop closes the connection unless it was already replaced
+func (r *Reconnector) drop(conn *Conn) {
+	r.mu.Lock()
+	if r.conn == conn {
+		r.conn = nil
+	}
+	r.mu.Unlock()
+	conn.fail(ErrClosed)
+}
+
+// temporary reports whether dial may succeed later, rejected handshakes other than
+// rate limiting and server errors are final
+func temporary(err error) bool {
+	var he HandshakeError
+	if !errors.As(err, &he) || he.StatusCode == http.StatusSwitchingProtocols {
+		return true
+	}
+	return he.StatusCode == http.StatusTooManyRequests || he.StatusCode >= 500
+}
@@ -0,0 +1,272 @@
+package websocket
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"collections/httpx"
+	"collections/httpx/hooks"
+)
+
+// accept upgrades the request to server side connection
+func accept(t *testing.T, w http.ResponseWriter, r *http.Request, cfg config) *Conn {
+	t.Helper()
+	sum := sha1.Sum([]byte(r.Header.Get("Sec-WebSocket-Key") + acceptGUID))
+	conn, brw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resp strings.Builder
+	resp.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n")
+	resp.WriteString("Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(sum[:]) + "\r\n")
+	if proto := r.Header.Get("Sec-WebSocket-Protocol"); proto != "" {
+		resp.WriteString("Sec-WebSocket-Protocol: " + strings.Split(proto, ",")[0] + "\r\n")
+	}
+	cfg.compress = cfg.compress && strings.Contains(r.Header.Get("Sec-WebSocket-Extensions"), extensionDeflate)
+	if cfg.compress {
+		resp.WriteString("Sec-WebSocket-Extensions: permessage-deflate; server_no_context_takeover; client_no_context_takeover\r\n")
+	}
+	resp.WriteString("\r\n")
+	if _, err := conn.Write([]byte(resp.String())); err != nil {
+		t.Fatal(err)
+	}
+	return newConn(conn, brw.Reader, false, cfg)
+}
+
+func wsURL(srv *httptest.Server) string {
+	return "ws" + strings.TrimPrefix(srv.URL, "http")
+}
+
+func TestEcho(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn := accept(t, w, r, config{compress: true, compressionLevel: -1, fragmentSize: 100})
+		for {
+			typ, msg, err := conn.ReadMessage(context.Background())
+			if err != nil {
+				return
+			}
+			if err := conn.WriteMessage(context.Background(), typ, msg); err != nil {
+				return
+			}
+		}
+	}))
+	defer srv.Close()
+
+	d := New(httpx.New(false), Options{
+		Subprotocols: []string{"chat", "json"},
+		Compression:  true,
+		FragmentSize: 10,
+		PingInterval: 5 * time.Millisecond,
+		PongTimeout:  time.Second,
+	})
+	conn, err := d.Dial(context.Background(), wsURL(srv))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conn.Subprotocol() != "chat" || !conn.cfg.compress {
+		t.Fatalf("unexpected negotiation subprotocol=%q, compress=%v", conn.Subprotocol(), conn.cfg.compress)
+	}
+
+	ctx := context.Background()
+	tests := []struct {
+		typ MessageType
+		msg []byte
+	}{
+		{TextMessage, []byte("hello")},
+		{TextMessage, []byte(strings.Repeat("compressed and fragmented ", 100))},
+		{BinaryMessage, bytes.Repeat([]byte{0, 1, 2, 255}, 20000)},
+		{BinaryMessage, nil},
+	}
+	for _, tt := range tests {
+		if err := conn.WriteMessage(ctx, tt.typ, tt.msg); err != nil {
+			t.Fatal(err)
+		}
+		// keepalive pings are answered between messages
+		time.Sleep(10 * time.Millisecond)
+		typ, msg, err := conn.ReadMessage(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if typ != tt.typ || !bytes.Equal(msg, tt.msg) {
+			t.Errorf("got type=%d, len=%d, want type=%d, len=%d", typ, len(msg), tt.typ, len(tt.msg))
+		}
+	}
+	if err := conn.Close(CloseNormalClosure, "bye"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := conn.ReadMessage(ctx); !errors.Is(err, ErrClosed) {
+		t.Fatalf("want ErrClosed, got %v", err)
+	}
+}
+
+func TestCloseHandshake(t *testing.T) {
+	got := make(chan error, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn := accept(t, w, r, config{})
+		_, _, err := conn.ReadMessage(context.Background())
+		got <- err
+	}))
+	defer srv.Close()
+
+	conn, err := New(httpx.New(false), Options{}).Dial(context.Background(), wsURL(srv))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.Close(CloseGoingAway, "bye"); err != nil {
+		t.Fatal(err)
+	}
+	var ce CloseError
+	if err := <-got; !errors.As(err, &ce) || ce.Code != CloseGoingAway || ce.Reason != "bye" {
+		t.Fatalf("unexpected server error %v", err)
+	}
+}
+
+func TestMaxMessageSize(t *testing.T) {
+	got := make(chan error, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn := accept(t, w, r, config{})
+		conn.WriteMessage(context.Background(), BinaryMessage, make([]byte, 1024))
+		_, _, err := conn.ReadMessage(context.Background())
+		got <- err
+	}))
+	defer srv.Close()
+
+	conn, err := New(httpx.New(false), Options{MaxMessageSize: 512}).Dial(context.Background(), wsURL(srv))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := conn.ReadMessage(context.Background()); !errors.Is(err, ErrMessageTooLarge) {
+		t.Fatalf("want ErrMessageTooLarge, got %v", err)
+	}
+	var ce CloseError
+	if err := <-got; !errors.As(err, &ce) || ce.Code != CloseMessageTooBig {
+		t.Fatalf("unexpected server error %v", err)
+	}
+}
+
+func TestPongTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn := accept(t, w, r, config{})
+		// never reads so pings are not answered
+		<-r.Context().Done()
+		conn.fail(ErrClosed)
+	}))
+	defer srv.Close()
+
+	d := New(httpx.New(false), Options{PingInterval: 10 * time.Millisecond})
+	conn, err := d.Dial(context.Background(), wsURL(srv))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := conn.ReadMessage(context.Background()); !errors.Is(err, ErrPongTimeout) {
+		t.Fatalf("want ErrPongTimeout, got %v", err)
+	}
+}
+
+func TestReconnector(t *testing.T) {
+	var dials atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch dials.Add(1) {
+		case 1:
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		case 2:
+			// connection is lost without close handshake
+			accept(t, w, r, config{}).fail(ErrClosed)
+		default:
+			conn := accept(t, w, r, config{})
+			if _, msg, err := conn.ReadMessage(context.Background()); err == nil {
+				conn.WriteMessage(context.Background(), TextMessage, append([]byte("hello "), msg...))
+			}
+			conn.ReadMessage(context.Background())
+		}
+	}))
+	defer srv.Close()
+
+	var connects atomic.Int32
+	d := New(httpx.New(false), Options{
+		Backoff: hooks.NewBackoffWithJitter(time.Millisecond, 5*time.Millisecond, hooks.WithoutJitter),
+		OnConnect: func(ctx context.Context, c *Conn) error {
+			if connects.Add(1) == 1 {
+				return nil
+			}
+			return c.WriteMessage(ctx, TextMessage, []byte("again"))
+		},
+	})
+	r := d.Reconnector(wsURL(srv))
+	defer r.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, msg, err := r.ReadMessage(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(msg) != "hello again" || dials.Load() != 3 || connects.Load() != 2 {
+		t.Fatalf("got %q after dials=%d, connects=%d", msg, dials.Load(), connects.Load())
+	}
+
+	// rejected handshake is not retried
+	forbidden := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+	}))
+	defer forbidden.Close()
+	_, _, err = New(httpx.New(false), Options{}).Reconnector(wsURL(forbidden)).ReadMessage(ctx)
+	var he HandshakeError
+	if !errors.As(err, &he) || he.StatusCode != http.StatusForbidden {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+// TestReconnectorClose checks that Close aborts reconnecting without waiting for backoff
+func TestReconnectorClose(t *testing.T) {
+	var dials atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		dials.Add(1)
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	d := New(httpx.New(false), Options{
+		Backoff: hooks.NewBackoffWithJitter(time.Hour, time.Hour, hooks.WithoutJitter),
+	})
+	r := d.Reconnector(wsURL(srv))
+	got := make(chan error, 1)
+	go func() {
+		_, _, err := r.ReadMessage(context.Background())
+		got <- err
+	}()
+	for dials.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	closed := make(chan error, 1)
+	go func() { closed <- r.Close() }()
+	select {
+	case err := <-closed:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("close is blocked by reconnecting")
+	}
+	select {
+	case err := <-got:
+		if !errors.Is(err, ErrClosed) {
+			t.Fatalf("want ErrClosed, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("reconnecting is not aborted by close")
+	}
+	if err := r.WriteMessage(context.Background(), TextMessage, nil); !errors.Is(err, ErrClosed) {
+		t.Fatalf("want ErrClosed after close, got %v", err)
+	}
+}
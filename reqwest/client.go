@@ -12,16 +12,16 @@ import (
 const HeaderUserAgent = "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
 
 type Reqwest struct {
-	client *http.Client
-	tracer *httptrace.ClientTrace
-	trace  bool
+	client    *http.Client
+	tracer    *httptrace.ClientTrace
+	traceOpts TraceOptions
+	trace     bool
 }
 
 func New(trace bool) *Reqwest {
 	return (&Reqwest{
 		client: &http.Client{},
 		trace:  trace,
-		tracer: getTracer(),
 	}).SetTransport(defaultTransport)
 }
 
@@ -60,6 +60,14 @@ func (r *Reqwest) SetTracer(tracer *httptrace.ClientTrace) *Reqwest {
 	return r
 }
 
+// SetTraceOptions enables tracing and configures the default [log/slog] based tracer.
+// It has no effect on the tracer provided with [Reqwest.SetTracer].
+func (r *Reqwest) SetTraceOptions(opts TraceOptions) *Reqwest {
+	r.traceOpts = opts
+	r.trace = true
+	return r
+}
+
 // Get is http get method
 func (r *Reqwest) Get(ctx context.Context, uri string, opts ...Options) (*http.Response, error) {
 	return request(ctx, r, http.MethodGet, uri, nil, opts...)
@@ -113,11 +121,6 @@ func request(
 	body io.Reader,
 	opts ...Options,
 ) (*http.Response, error) {
-	// if trace is available
-	if r.trace {
-		ctx = httptrace.WithClientTrace(ctx, r.tracer)
-	}
-
 	// initiate options for headers and queries
 	ho := &HTTPOptions{}
 	for _, o := range opts {
@@ -147,5 +150,14 @@ func request(
 		req.URL.RawQuery = q.Encode()
 	}
 
+	// if trace is available
+	if r.trace {
+		tracer := r.tracer
+		if tracer == nil {
+			tracer = getTracer(r.traceOpts, req)
+		}
+		req = req.WithContext(httptrace.WithClientTrace(req.Context(), tracer))
+	}
+
 	return r.client.Do(req)
 }
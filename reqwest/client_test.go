@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"io"
 	"log"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -242,6 +243,45 @@ func TestClientMisc(t *testing.T) {
 	}
 }
 
+// Test slog based tracing emits structured records with redacted headers
+func TestClientTrace(t *testing.T) {
+	ts := mockHTTPServer()
+	t.Cleanup(ts.Close)
+
+	var buf bytes.Buffer
+	c := New(false).SetTraceOptions(TraceOptions{
+		Logger:  slog.New(slog.NewJSONHandler(&buf, nil)),
+		Headers: true,
+	})
+	res, err := c.Get(context.Background(), ts.URL, WithHeaders(map[string]string{
+		"Authorization": "Bearer secret",
+		RequestIDHeader: "req-1",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	phases := map[string]bool{}
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var rec map[string]any
+		if err := dec.Decode(&rec); err != nil {
+			t.Fatal(err)
+		}
+		equals(t, rec["request_id"], "req-1")
+		if rec["key"] == "Authorization" {
+			equals(t, rec["value"], "[REDACTED]")
+		}
+		phases[rec["phase"].(string)] = true
+	}
+	for _, p := range []string{"get_conn", "connect", "got_conn", "wrote_request", "first_response_byte"} {
+		if !phases[p] {
+			t.Errorf("missing trace record for phase %s", p)
+		}
+	}
+}
+
 // helper for equality
 func equals(t testing.TB, got, want any) bool {
 	t.Helper()
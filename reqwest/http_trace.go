@@ -1,57 +1,208 @@
 package reqwest
 
 import (
+	"context"
+	"crypto/rand"
 	"crypto/tls"
-	"log"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
 	"net/http/httptrace"
+	"strings"
+	"sync"
+	"time"
 )
 
-func getTracer() *httptrace.ClientTrace {
+// RequestIDHeader is the header used for correlating trace records of single request.
+// If request doesn't carry it random request id will be generated for the trace.
+const RequestIDHeader = "X-Request-Id"
+
+const redacted = "[REDACTED]"
+
+// TraceOptions configures the [log/slog] based request tracing.
+type TraceOptions struct {
+	// Logger receives the trace records, if nil [log/slog.Default] will be used
+	Logger *slog.Logger
+	// Level for records of successful phases, zero value is [log/slog.LevelInfo]
+	Level slog.Level
+	// ErrorLevel for records of failed phases, nil is [log/slog.LevelError]
+	ErrorLevel *slog.Level
+	// Headers enables record for every request header written on the wire
+	Headers bool
+	// RedactHeaders are the header names whose values are replaced in records.
+	// Authorization, Proxy-Authorization and Cookie are always redacted.
+	RedactHeaders []string
+}
+
+// getTracer returns [net/http/httptrace.ClientTrace] which emits structured records
+// for every phase of the request with request id, host, phase, duration and error attributes.
+func getTracer(opts TraceOptions, req *http.Request) *httptrace.ClientTrace {
+	if opts.Logger == nil {
+		opts.Logger = slog.Default()
+	}
+	errorLevel := slog.LevelError
+	if opts.ErrorLevel != nil {
+		errorLevel = *opts.ErrorLevel
+	}
+
+	redact := map[string]struct{}{
+		"Authorization":       {},
+		"Proxy-Authorization": {},
+		"Cookie":              {},
+	}
+	for _, h := range opts.RedactHeaders {
+		redact[http.CanonicalHeaderKey(h)] = struct{}{}
+	}
+
+	reqID := req.Header.Get(RequestIDHeader)
+	if reqID == "" {
+		reqID = newRequestID()
+	}
+
+	t := &slogTracer{
+		opts:       opts,
+		errorLevel: errorLevel,
+		ctx:        req.Context(),
+		redact:     redact,
+		start:      time.Now(),
+		attrs: []slog.Attr{
+			slog.String("request_id", reqID),
+			slog.String("method", req.Method),
+			slog.String("host", req.URL.Host),
+		},
+		connectStart: make(map[string]time.Time),
+	}
+	return t.clientTrace()
+}
+
+type slogTracer struct {
+	ctx          context.Context
+	opts         TraceOptions
+	errorLevel   slog.Level
+	redact       map[string]struct{}
+	start        time.Time
+	attrs        []slog.Attr
+	mu           sync.Mutex
+	dnsStart     time.Time
+	tlsStart     time.Time
+	connectStart map[string]time.Time
+}
+
+func (t *slogTracer) clientTrace() *httptrace.ClientTrace {
 	return &httptrace.ClientTrace{
 		GetConn: func(addr string) {
-			log.Printf("connection to host and port = %s\n", addr)
+			t.log("get_conn", time.Since(t.start), nil, slog.String("addr", addr))
 		},
 		GotConn: func(info httptrace.GotConnInfo) {
-			log.Printf("connection acquired: %+v\n", info)
+			attrs := []slog.Attr{
+				slog.Bool("reused", info.Reused),
+				slog.Bool("was_idle", info.WasIdle),
+				slog.Duration("idle_time", info.IdleTime),
+			}
+			if info.Conn != nil {
+				attrs = append(attrs, slog.String("remote_addr", info.Conn.RemoteAddr().String()))
+			}
+			t.log("got_conn", time.Since(t.start), nil, attrs...)
 		},
 		PutIdleConn: func(err error) {
 			if err != nil {
-				log.Printf("put idle conn: %+v\n", err)
+				t.log("put_idle_conn", 0, err)
 			}
 		},
 		GotFirstResponseByte: func() {
-			log.Println("got first response byte")
+			t.log("first_response_byte", time.Since(t.start), nil)
 		},
-		DNSStart: func(info httptrace.DNSStartInfo) {
-			log.Printf("dns started for host: %s\n", info.Host)
+		DNSStart: func(httptrace.DNSStartInfo) {
+			t.mu.Lock()
+			t.dnsStart = time.Now()
+			t.mu.Unlock()
 		},
 		DNSDone: func(info httptrace.DNSDoneInfo) {
-			log.Printf("dns resvolver done: %+v\n", info)
+			t.mu.Lock()
+			d := time.Since(t.dnsStart)
+			t.mu.Unlock()
+			addrs := make([]string, 0, len(info.Addrs))
+			for _, a := range info.Addrs {
+				addrs = append(addrs, a.String())
+			}
+			t.log("dns", d, info.Err, slog.Any("addrs", addrs), slog.Bool("coalesced", info.Coalesced))
 		},
 		ConnectStart: func(network, addr string) {
-			log.Printf("connection started at network: %s and addr: %s\n", network, addr)
+			t.mu.Lock()
+			t.connectStart[network+addr] = time.Now()
+			t.mu.Unlock()
 		},
 		ConnectDone: func(network, addr string, err error) {
-			log.Printf("connection done at network: %s and addr: %s\n", network, addr)
+			t.mu.Lock()
+			d := time.Since(t.connectStart[network+addr])
+			t.mu.Unlock()
+			t.log("connect", d, err, slog.String("network", network), slog.String("addr", addr))
 		},
 		TLSHandshakeStart: func() {
-			log.Println("tls handshake started")
+			t.mu.Lock()
+			t.tlsStart = time.Now()
+			t.mu.Unlock()
 		},
 		TLSHandshakeDone: func(state tls.ConnectionState, err error) {
+			t.mu.Lock()
+			d := time.Since(t.tlsStart)
+			t.mu.Unlock()
 			if err != nil {
-				log.Printf("tls handshake done: %+v\n", state)
-			} else {
-				log.Println("tls handshake done with err:", err)
+				t.log("tls_handshake", d, err)
+				return
 			}
+			t.log(
+				"tls_handshake",
+				d,
+				nil,
+				slog.String("version", tls.VersionName(state.Version)),
+				slog.String("cipher_suite", tls.CipherSuiteName(state.CipherSuite)),
+				slog.String("server_name", state.ServerName),
+				slog.Bool("resumed", state.DidResume),
+			)
 		},
 		WroteHeaderField: func(key string, value []string) {
-			log.Printf("header field written key: %s, value: %v\n", key, value)
+			if !t.opts.Headers {
+				return
+			}
+			if _, ok := t.redact[http.CanonicalHeaderKey(key)]; ok {
+				value = []string{redacted}
+			}
+			t.log("wrote_header", 0, nil, slog.String("key", key), slog.String("value", strings.Join(value, ", ")))
 		},
 		WroteHeaders: func() {
-			log.Println("writing of headers completed")
+			t.log("wrote_headers", time.Since(t.start), nil)
 		},
 		WroteRequest: func(info httptrace.WroteRequestInfo) {
-			log.Printf("writing of request completed: %+v\n", info)
+			t.log("wrote_request", time.Since(t.start), info.Err)
 		},
 	}
 }
+
+func (t *slogTracer) log(phase string, d time.Duration, err error, extra ...slog.Attr) {
+	level := t.opts.Level
+	if err != nil {
+		level = t.errorLevel
+	}
+	if !t.opts.Logger.Enabled(t.ctx, level) {
+		return
+	}
+	attrs := make([]slog.Attr, 0, len(t.attrs)+len(extra)+3)
+	attrs = append(attrs, t.attrs...)
+	attrs = append(attrs, slog.String("phase", phase))
+	if d > 0 {
+		attrs = append(attrs, slog.Duration("duration", d))
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	attrs = append(attrs, extra...)
+	t.opts.Logger.LogAttrs(t.ctx, level, "http trace", attrs...)
+}
+
+// newRequestID returns random 16 characters hex request id.
+func newRequestID() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
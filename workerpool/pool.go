@@ -33,23 +33,31 @@ func New[T any](count int) *WorkerPool[T] {
 		count: count,
 		ch:    make(chan WorkFunc[T], 1),
 		ResCh: make(chan *Response[T], 1),
+		stats: make(map[int]*Stats, count),
 	}
 }
 
 // Run is main method on WorkerPool type which will spin up workerpool
-// and wait for all the task to complete please make sure this is blocking method
+// and wait for all the task to complete please make sure this is blocking method.
+// ResCh is closed once all the workers are done after [WorkerPool.Close].
 func (wp *WorkerPool[T]) Run() {
 	for i := 1; i <= wp.count; i++ {
 		wp.wg.Add(1)
 		go wp.worker(i)
 	}
 	wp.wg.Wait()
+	close(wp.ResCh)
+}
+
+// Submit queues the work, it blocks till one of the workers is ready to take it
+func (wp *WorkerPool[T]) Submit(fn WorkFunc[T]) {
+	wp.ch <- fn
 }
 
-// Close closes the workerpool
+// Close closes the workerpool, no work can be submitted after it. Queued work
+// is still completed and it's response is sent on ResCh.
 func (wp *WorkerPool[T]) Close() {
 	close(wp.ch)
-	close(wp.ResCh)
 }
 
 func (wp *WorkerPool[T]) worker(id int) {
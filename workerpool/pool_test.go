@@ -0,0 +1,44 @@
+package workerpool
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestSubmitClose checks that queued work is completed after Close and ResCh is closed
+func TestSubmitClose(t *testing.T) {
+	wp := New[int](3)
+	go wp.Run()
+
+	errOdd := errors.New("odd")
+	go func() {
+		for i := range 10 {
+			wp.Submit(func() (int, error) {
+				if i%2 == 1 {
+					return i, errOdd
+				}
+				return i, nil
+			})
+		}
+		wp.Close()
+	}()
+
+	var sum, failed int
+	for res := range wp.ResCh {
+		sum += res.Res
+		if errors.Is(res.Err, errOdd) {
+			failed++
+		}
+	}
+	if sum != 45 || failed != 5 {
+		t.Fatalf("wanted sum 45 with 5 errors got %d with %d", sum, failed)
+	}
+
+	tasks := 0
+	for _, s := range wp.Stats() {
+		tasks += s.TaskCount
+	}
+	if tasks != 10 {
+		t.Errorf("wanted 10 completed tasks in stats got %d", tasks)
+	}
+}